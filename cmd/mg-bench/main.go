@@ -1,12 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 
 	"sourcegraph.com/sourcegraph/appdash"
@@ -20,6 +20,16 @@ import (
 // Execute execudes the commands with the given arguments and returns an error,
 // if any.
 func main() {
+	profile := flag.String("profile", oauth.ProfileName(), "credentials profile to use")
+	flag.Parse()
+
+	p, err := oauth.LoadProfile(*profile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	testdomain := p.APIHost("api.stagingf.we7.com")
+
 	// We create a new in-memory store. All information about traces will
 	// eventually be stored here.
 	store := appdash.NewMemoryStore()
@@ -79,26 +89,17 @@ func main() {
 			Transport: &httptrace.Transport{Recorder: rec, SetName: true, Transport: netTraceTransport},
 		}
 
-		testdomain := "api.stagingf.we7.com"
-
-		creds := oauth.DefaultCredentials()
-		creds.ClientID = os.Getenv("OAUTH_CLIENT_ID")
-		creds.ClientSecret = os.Getenv("OAUTH_CLIENT_SECRET")
-		creds.Username = os.Getenv("OAUTH_USERNAME")
-		creds.Password = os.Getenv("OAUTH_PASSWORD")
-
-		oc := oauth.New()
-		oc.Option(oauth.HTTPClient(httpClient))
-		oc.AddDomain(testdomain, creds)
+		oc := oauth.New(oauth.HTTPClient(httpClient))
+		if err := p.AddTo(oc, testdomain); err != nil {
+			log.Println(err)
+			return
+		}
 
-		c := mg.New()
-		c.ApiKey = "test"
-		c.Host = testdomain
-		c.Option(mg.OAuthClient(oc))
+		c := mg.New(mg.ApiKey("test"), mg.Host(testdomain), mg.OAuthClient(oc))
 
-		r, _ := c.SimpleSearch("jimi hendrix purple haze", []string{"tracks"})
+		r, _ := c.SimpleSearch("jimi hendrix purple haze", []mg.SearchType{mg.SearchTracks})
 
-		r, _ = c.SimpleSearch("jimi hendrix purple haze", []string{"tracks"})
+		r, _ = c.SimpleSearch("jimi hendrix purple haze", []mg.SearchType{mg.SearchTracks})
 
 		t := r.Tracks[0]
 		s, err := c.StreamInfo(t.Id, "RADIO", 0, []string{"MP3"})
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	mg "github.com/we7/go-mediagraft/pkg/mediagraft"
+)
+
+// export implements "mg export [flags] KIND ID", writing the tracks of
+// an album, playlist, station or search as a playlist file
+func export(c *mg.Client, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "m3u8", "playlist format: m3u8, xspf, jspf or json")
+	out := fs.String("o", "", "file to write to, defaults to stdout")
+	stream := fs.Bool("stream", false, "include stream locations from streamInfo")
+	source := fs.String("source", "PLAYLIST", "play source sent when getting stream locations")
+	formats := fs.String("formats", "MP3", "comma separated music formats for stream locations")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: mg export [flags] album ID | playlist ID | station IDENT | search QUERY")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	var e *mg.Export
+	switch kind, id := fs.Arg(0), fs.Arg(1); kind {
+	case "album":
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			log.Fatalf("bad album id %q", id)
+		}
+		as, err := c.AlbumsInfo(mg.AlbumID(n))
+		var perr *mg.PartialResultError
+		if errors.As(err, &perr) {
+			log.Fatalf("album %d not found", n)
+		} else if err != nil {
+			log.Fatal(err)
+		}
+		e = mg.ExportAlbum(&as[0])
+	case "playlist":
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			log.Fatalf("bad playlist id %q", id)
+		}
+		p, err := c.GetPlaylist(mg.PlaylistID(n))
+		if err != nil {
+			log.Fatal(err)
+		}
+		e = mg.ExportPlaylist(p)
+	case "station":
+		s, err := c.GetStation(mg.StationIdent(id))
+		if err != nil {
+			log.Fatal(err)
+		}
+		e = mg.ExportStation(s)
+	case "search":
+		r, err := c.SimpleSearch(id, []mg.SearchType{mg.SearchTracks})
+		if err != nil {
+			log.Fatal(err)
+		}
+		e = mg.ExportSearch(id, r)
+	default:
+		log.Fatalf("unknown kind %q, expected album, playlist, station or search", kind)
+	}
+
+	if *stream {
+		if err := c.StreamLocations(e, *source, strings.Split(*formats, ",")); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *out == "" {
+		if err := e.Write(os.Stdout, mg.PlaylistFormat(*format)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = e.Write(f, mg.PlaylistFormat(*format))
+	// Close even if the write failed, a failed close may lose data too
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
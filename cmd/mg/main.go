@@ -1,34 +1,49 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"os"
 
 	mg "github.com/we7/go-mediagraft/pkg/mediagraft"
 	"github.com/we7/go-mediagraft/pkg/mediagraft/oauth"
 )
 
+var testdomain = "api.stagingf.we7.com"
+
+var profile = flag.String("profile", oauth.ProfileName(), "credentials profile to use")
+
 func main() {
-	testdomain := "api.stagingf.we7.com"
-	creds := oauth.DefaultCredentials()
-	creds.ClientID = os.Getenv("OAUTH_CLIENT_ID")
-	creds.ClientSecret = os.Getenv("OAUTH_CLIENT_SECRET")
-	creds.Username = os.Getenv("OAUTH_USERNAME")
-	creds.Password = os.Getenv("OAUTH_PASSWORD")
+	flag.Parse()
+
+	p, err := oauth.LoadProfile(*profile)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	c := mg.DefaultClient
-	c.ApiKey = "sonos"
-	c.Host = testdomain
-	c.OAuthClient().AddDomain(testdomain, creds)
+	switch args := flag.Args(); {
+	case len(args) >= 2 && args[0] == "auth" && args[1] == "sign":
+		authSign(p, args[2:])
+		return
+	case len(args) >= 2 && args[0] == "playlist" && args[1] == "import":
+		playlistImport(newClient(p), args[2:])
+		return
+	case len(args) >= 1 && args[0] == "export":
+		export(newClient(p), args[1:])
+		return
+	case len(args) != 0:
+		log.Fatalf("unknown command %q", args)
+	}
 
-	r, _ := c.SimpleSearch("jimi hendrix purple haze", []string{"tracks"})
+	c := newClient(p)
+
+	r, _ := c.SimpleSearch("jimi hendrix purple haze", []mg.SearchType{mg.SearchTracks})
 
 	t := r.Tracks[0]
 	log.Println(t)
 	log.Println(t.Images)
 
 	/*
-			sid := mg.StationIdent(fmt.Sprintf("a%v", a.Id))
+			sid := mg.ArtistStation(a.Id)
 			s, err := c.GetStation(sid)
 			log.Println(err)
 			log.Printf("%+v", s)
@@ -52,3 +67,12 @@ func main() {
 
 	return
 }
+
+// newClient returns a client authenticated with the profile
+func newClient(p *oauth.Profile) *mg.Client {
+	c := mg.New(mg.ApiKey("sonos"), mg.Host(p.APIHost(testdomain)))
+	if err := p.AddTo(c.OAuthClient(), testdomain); err != nil {
+		log.Fatal(err)
+	}
+	return c
+}
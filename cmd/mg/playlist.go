@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	mg "github.com/we7/go-mediagraft/pkg/mediagraft"
+)
+
+// playlistImport implements "mg playlist import [flags] FILE", matching
+// the tracks of a playlist file and printing the matched track ids and
+// a CSV report of the matches.
+func playlistImport(c *mg.Client, args []string) {
+	fs := flag.NewFlagSet("playlist import", flag.ExitOnError)
+	format := fs.String("format", "", "playlist format: m3u, m3u8, xspf, jspf or csv, defaults to the file's extension")
+	report := fs.String("report", "", "file to write the CSV match report to, defaults to stderr")
+	threshold := fs.Float64("threshold", mg.DefaultMatchThreshold, "least confidence, from 0 to 1, to accept a match")
+	concurrency := fs.Int("concurrency", mg.DefaultMatchConcurrency, "most tracks matched at once")
+	interval := fs.Duration("interval", mg.DefaultMatchInterval, "least time between API calls")
+	fallback := fs.Bool("fallback", true, "fall back to a search when findMatch finds no good match")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: mg playlist import [flags] FILE")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	f := mg.PlaylistFormat(*format)
+	if f == "" {
+		var err error
+		if f, err = mg.PlaylistFormatFor(fs.Arg(0)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer in.Close()
+
+	m := mg.NewMatcher(c,
+		mg.MatchThreshold(*threshold),
+		mg.MatchConcurrency(*concurrency),
+		mg.MatchInterval(*interval),
+		mg.MatchFallback(*fallback),
+	)
+	start := time.Now()
+	imp, err := m.ImportPlaylist(in, f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, t := range imp.Tracks {
+		fmt.Printf("%d\t%s\t%s\n", t.Id, t.ArtistName(), t.Title)
+	}
+
+	out := os.Stderr
+	if *report != "" {
+		if out, err = os.Create(*report); err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+	if err := imp.Report.WriteCSV(out); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("matched %d of %d tracks in %v", len(imp.Tracks), len(imp.Report.Matches), time.Since(start).Round(time.Millisecond))
+}
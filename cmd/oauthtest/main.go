@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -12,21 +13,26 @@ import (
 
 var testdomain = "api.stagingf.we7.com"
 
+var profile = flag.String("profile", oauth.ProfileName(), "credentials profile to use")
+
 func main() {
+	flag.Parse()
 
-	c := oauth.DefaultClient
+	p, err := oauth.LoadProfile(*profile)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	creds := oauth.DefaultCredentials()
-	creds.ClientID = os.Getenv("OAUTH_CLIENT_ID")
-	creds.ClientSecret = os.Getenv("OAUTH_CLIENT_SECRET")
-	creds.Username = os.Getenv("OAUTH_USERNAME")
-	creds.Password = os.Getenv("OAUTH_PASSWORD")
+	domain := p.APIHost(testdomain)
 
-	c.AddDomain(testdomain, creds)
+	c := oauth.New()
+	if err := p.AddTo(c, testdomain); err != nil {
+		log.Fatal(err)
+	}
 
 	rurl := fmt.Sprintf("http://%s/api/0.1/simpleSearch?apiKey=%s&appVersion=%s&format=json&type=genres&query=blues",
-		testdomain,
-		creds.ApiKey,
+		domain,
+		p.ApiKey,
 		"1",
 	)
 
@@ -1,35 +1,20 @@
 package mediagraft
 
-import (
-	"encoding/json"
-	"net/url"
-	"strconv"
-	"strings"
-)
-
-func (c *Client) AlbumsInfo(albumId ...int32) ([]Album, error) {
-	args := &url.Values{}
-
-	var strids []string
-	for _, t := range albumId {
-		strids = append(strids, strconv.Itoa(int(t)))
-	}
-	args.Set("ids", strings.Join(strids, ","))
-	args.Set("detail", "full")
-
-	r, err := c.Call("GET", "albumsInfo", args, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Body.Close()
+// AlbumsInfo returns the albums with the given ids, in the same order.
+// If some albums were not found the others are returned along with a
+// *PartialResultError.
+func (c *Client) AlbumsInfo(albumId ...AlbumID) ([]Album, error) {
+	return lookup(c, albumsLookup, intIDs(albumId))
+}
 
-	var a []Album
-	//io.Copy(os.Stdout, r.Body)
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&a)
-	if err != nil {
-		return nil, err
-	}
+// AlbumsInfoRaw returns the undecoded response to a single albumsInfo
+// call for the ids
+func (c *Client) AlbumsInfoRaw(albumId ...AlbumID) (*RawResponse, error) {
+	return albumsLookup.raw(c, intIDs(albumId))
+}
 
-	return a, nil
+// AlbumsInfoIter returns an iterator over the albums with the given
+// ids, decoding each album and its tracks as they are read
+func (c *Client) AlbumsInfoIter(albumId ...AlbumID) *Iterator[Album] {
+	return albumsLookup.iter(c, intIDs(albumId))
 }
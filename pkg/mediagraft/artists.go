@@ -1,35 +1,20 @@
 package mediagraft
 
-import (
-	"encoding/json"
-	"net/url"
-	"strconv"
-	"strings"
-)
-
-func (c *Client) ArtistsInfo(albumId ...int32) ([]Artist, error) {
-	args := &url.Values{}
-
-	var strids []string
-	for _, t := range albumId {
-		strids = append(strids, strconv.Itoa(int(t)))
-	}
-	args.Set("ids", strings.Join(strids, ","))
-	args.Set("detail", "full")
-
-	r, err := c.Call("GET", "artistsInfo", args, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Body.Close()
+// ArtistsInfo returns the artists with the given ids, in the same
+// order. If some artists were not found the others are returned along
+// with a *PartialResultError.
+func (c *Client) ArtistsInfo(artistId ...ArtistID) ([]Artist, error) {
+	return lookup(c, artistsLookup, intIDs(artistId))
+}
 
-	var a []Artist
-	//io.Copy(os.Stdout, r.Body)
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&a)
-	if err != nil {
-		return nil, err
-	}
+// ArtistsInfoRaw returns the undecoded response to a single
+// artistsInfo call for the ids
+func (c *Client) ArtistsInfoRaw(artistId ...ArtistID) (*RawResponse, error) {
+	return artistsLookup.raw(c, intIDs(artistId))
+}
 
-	return a, nil
+// ArtistsInfoIter returns an iterator over the artists with the given
+// ids, decoding them as they are read
+func (c *Client) ArtistsInfoIter(artistId ...ArtistID) *Iterator[Artist] {
+	return artistsLookup.iter(c, intIDs(artistId))
 }
@@ -0,0 +1,417 @@
+package mediagraft
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a cached response is fresh for if the
+// server gives no Cache-Control max-age or Expires header
+const DefaultCacheTTL = time.Hour
+
+// CacheableMethods are the API methods whose responses may be cached.
+// Per-user and streaming methods must never be added.
+var CacheableMethods = map[string]bool{
+	"tracksInfo":  true,
+	"albumsInfo":  true,
+	"artistsInfo": true,
+}
+
+// CacheEntry is a cached API response
+type CacheEntry struct {
+	Header  http.Header
+	Body    []byte
+	ETag    string
+	Expires time.Time // The response must be revalidated after this
+}
+
+// Cache stores API responses, keyed on the full URL of the call, so a
+// cache may be shared between clients of different servers.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, e *CacheEntry)
+	Delete(key string)
+}
+
+// ResponseCache sets the cache used for CacheableMethods, responses
+// are not cached if this is nil, which is the default
+func ResponseCache(cache Cache) option {
+	return func(c *Client) option {
+		previous := c.cache
+		c.cache = cache
+		return ResponseCache(previous)
+	}
+}
+
+func (c *Client) ResponseCache() Cache {
+	return c.cache
+}
+
+// CacheTTL sets how long cached responses are fresh for when the
+// server doesn't say
+func CacheTTL(d time.Duration) option {
+	return func(c *Client) option {
+		previous := c.cacheTTL
+		c.cacheTTL = d
+		return CacheTTL(previous)
+	}
+}
+
+func (c *Client) CacheTTL() time.Duration {
+	return c.cacheTTL
+}
+
+// idParams are the query parameters holding comma separated ids, the
+// order of the ids doesn't change the response
+var idParams = []string{"ids", "versionIds"}
+
+// cacheKey returns the key to cache the response to a request for u
+// under. It is the whole URL, including the virtual host if there is
+// one, with the query in a canonical order.
+func cacheKey(u *url.URL, hostName string) string {
+	vs := u.Query()
+	for _, p := range idParams {
+		for i, v := range vs[p] {
+			ids := strings.Split(v, ",")
+			sort.Strings(ids)
+			vs[p][i] = strings.Join(ids, ",")
+		}
+	}
+
+	k := *u
+	k.RawQuery = vs.Encode()
+	if hostName != "" {
+		return hostName + " " + k.String()
+	}
+	return k.String()
+}
+
+// cachedDo serves the request from the cache if we have a fresh
+// response, revalidating stale responses that have an ETag
+func (c *Client) cachedDo(key string, r *http.Request) (*http.Response, error) {
+	now := time.Now()
+
+	e, ok := c.cache.Get(key)
+	if ok && now.Before(e.Expires) {
+		return e.response(r), nil
+	}
+	if ok && e.ETag != "" {
+		r.Header.Set("If-None-Match", e.ETag)
+	}
+
+	resp, err := c.OAuthClient().Do(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		resp.Body.Close()
+		if exp, store := c.expiry(resp.Header, now); store {
+			e.Expires = exp
+			c.cache.Set(key, e)
+		} else {
+			c.cache.Delete(key)
+		}
+		return e.response(r), nil
+	case resp.StatusCode != http.StatusOK:
+		return resp, nil
+	}
+
+	exp, store := c.expiry(resp.Header, now)
+	if !store {
+		c.cache.Delete(key)
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.cache.Set(key, &CacheEntry{
+		Header:  resp.Header.Clone(),
+		Body:    body,
+		ETag:    resp.Header.Get("ETag"),
+		Expires: exp,
+	})
+
+	return resp, nil
+}
+
+// expiry works out when a response fetched at now goes stale from its
+// Cache-Control and Expires headers, and whether it may be stored
+func (c *Client) expiry(h http.Header, now time.Time) (time.Time, bool) {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		switch {
+		case d == "no-store":
+			return time.Time{}, false
+		case d == "no-cache":
+			return now, true
+		case strings.HasPrefix(d, "max-age="):
+			if secs, err := strconv.Atoi(d[len("max-age="):]); err == nil {
+				return now.Add(time.Duration(secs) * time.Second), true
+			}
+		}
+	}
+
+	if v := h.Get("Expires"); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil {
+			// An invalid Expires means already expired
+			return now, true
+		}
+		return t, true
+	}
+
+	return now.Add(c.cacheTTL), true
+}
+
+// response returns a copy of the cached response for the request
+func (e *CacheEntry) response(r *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       r,
+	}
+}
+
+// MemoryCache is an in-memory LRU Cache
+type MemoryCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryCacheItem struct {
+	key    string
+	entry  *CacheEntry
+	stored time.Time
+}
+
+// NewMemoryCache returns an LRU cache holding at most maxEntries
+// responses, each kept for at most ttl after it was stored, so that
+// stale entries can still be revalidated. A ttl of 0 keeps entries
+// until they are evicted.
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*memoryCacheItem)
+	if m.ttl > 0 && time.Since(item.stored) > m.ttl {
+		m.remove(el)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(el)
+	e := *item.entry
+	return &e, true
+}
+
+func (m *MemoryCache) Set(key string, e *CacheEntry) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	item := &memoryCacheItem{key: key, entry: e, stored: time.Now()}
+	if el, ok := m.entries[key]; ok {
+		el.Value = item
+		m.lru.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(item)
+	for m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		m.remove(m.lru.Back())
+	}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+}
+
+func (m *MemoryCache) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.lru.Len()
+}
+
+func (m *MemoryCache) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.entries, el.Value.(*memoryCacheItem).key)
+}
+
+// DiskCache is a Cache storing one file per response in a directory.
+// The directory should only be used by the cache, any other files in
+// it may be pruned.
+type DiskCache struct {
+	dir        string
+	maxEntries int
+	ttl        time.Duration
+
+	lock sync.Mutex // Serialises pruning
+}
+
+// NewDiskCache returns a cache storing responses in dir, which is
+// created if needed. Like NewMemoryCache it holds at most maxEntries
+// responses, evicting the least recently used, each kept for at most
+// ttl after it was stored. Zero for either leaves the cache unbounded,
+// call Prune to remove old entries from an unbounded cache.
+func NewDiskCache(dir string, maxEntries int, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, maxEntries: maxEntries, ttl: ttl}, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	p := d.path(key)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	if fi, err := f.Stat(); err != nil || d.expired(fi, time.Now()) {
+		os.Remove(p)
+		return nil, false
+	}
+
+	var e CacheEntry
+	if err := json.NewDecoder(f).Decode(&e); err != nil {
+		return nil, false
+	}
+
+	// The modification time records when the entry was last used, for
+	// eviction, a read only cache still works without it
+	now := time.Now()
+	os.Chtimes(p, now, now)
+	return &e, true
+}
+
+// Set writes the entry, errors are ignored as the response can always
+// be fetched again
+func (d *DiskCache) Set(key string, e *CacheEntry) {
+	f, err := os.CreateTemp(d.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+
+	err = json.NewEncoder(f).Encode(e)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+
+	if os.Rename(f.Name(), d.path(key)) == nil && d.maxEntries > 0 {
+		d.Prune()
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
+
+// Prune removes the entries older than the cache's ttl, and the least
+// recently used entries over its maxEntries
+func (d *DiskCache) Prune() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var kept []os.FileInfo
+	for _, de := range entries {
+		if !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		fi, err := de.Info()
+		if err != nil {
+			continue
+		}
+		if d.expired(fi, now) {
+			os.Remove(filepath.Join(d.dir, fi.Name()))
+			continue
+		}
+		kept = append(kept, fi)
+	}
+
+	if d.maxEntries <= 0 || len(kept) <= d.maxEntries {
+		return nil
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].ModTime().Before(kept[j].ModTime()) })
+	for _, fi := range kept[:len(kept)-d.maxEntries] {
+		os.Remove(filepath.Join(d.dir, fi.Name()))
+	}
+	return nil
+}
+
+func (d *DiskCache) Len() int {
+	entries, _ := os.ReadDir(d.dir)
+	n := 0
+	for _, de := range entries {
+		if strings.HasSuffix(de.Name(), ".json") {
+			n++
+		}
+	}
+	return n
+}
+
+// expired returns true if the entry's file has outlived the ttl
+func (d *DiskCache) expired(fi os.FileInfo, now time.Time) bool {
+	return d.ttl > 0 && now.Sub(fi.ModTime()) > d.ttl
+}
@@ -0,0 +1,248 @@
+package mediagraft
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheLRU(t *testing.T) {
+	m := NewMemoryCache(2, 0)
+	m.Set("a", &CacheEntry{Body: []byte("a")})
+	m.Set("b", &CacheEntry{Body: []byte("b")})
+	m.Get("a")
+	m.Set("c", &CacheEntry{Body: []byte("c")})
+
+	if _, ok := m.Get("b"); ok {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if e, ok := m.Get(k); !ok || string(e.Body) != k {
+			t.Errorf("expected entry %s to be cached", k)
+		}
+	}
+
+	m.Delete("a")
+	if _, ok := m.Get("a"); ok || m.Len() != 1 {
+		t.Errorf("expected entry a to be deleted")
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	m := NewMemoryCache(0, 10*time.Millisecond)
+	m.Set("a", &CacheEntry{})
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := m.Get("a"); ok {
+		t.Errorf("expected the entry to have expired")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	d, err := NewDiskCache(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Minute).Round(time.Second)
+	d.Set("tracksInfo?ids=1", &CacheEntry{
+		Header:  http.Header{"Etag": {`"v1"`}},
+		Body:    []byte(`[{"trackId":"1"}]`),
+		ETag:    `"v1"`,
+		Expires: exp,
+	})
+
+	e, ok := d.Get("tracksInfo?ids=1")
+	if !ok {
+		t.Fatal("expected the entry to be cached")
+	}
+	if string(e.Body) != `[{"trackId":"1"}]` || e.ETag != `"v1"` || !e.Expires.Equal(exp) || e.Header.Get("ETag") != `"v1"` {
+		t.Errorf("entry did not round trip: %+v", e)
+	}
+
+	d.Delete("tracksInfo?ids=1")
+	if _, ok := d.Get("tracksInfo?ids=1"); ok {
+		t.Errorf("expected the entry to be deleted")
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	d, err := NewDiskCache(t.TempDir(), 2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set the last use times directly, the file system's clock may
+	// not be fine grained enough to order them
+	age := func(key string, ago time.Duration) {
+		at := time.Now().Add(-ago)
+		if err := os.Chtimes(d.path(key), at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d.Set("a", &CacheEntry{Body: []byte("a")})
+	age("a", 3*time.Minute)
+	d.Set("b", &CacheEntry{Body: []byte("b")})
+	age("b", 2*time.Minute)
+	if _, ok := d.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	d.Set("c", &CacheEntry{Body: []byte("c")})
+
+	if _, ok := d.Get("b"); ok {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := d.Get(k); !ok {
+			t.Errorf("expected %s to be cached", k)
+		}
+	}
+	if d.Len() != 2 {
+		t.Errorf("expected 2 entries got %d", d.Len())
+	}
+
+	age("a", 2*time.Hour)
+	if err := d.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 1 {
+		t.Errorf("expected the expired entry to be pruned, got %d entries", d.Len())
+	}
+	if _, ok := d.Get("c"); !ok {
+		t.Errorf("expected c to be cached")
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	stats := &lookupStats{}
+	tracks := tracksInfoHandler(t, "ids", nil, DefaultLookupChunkSize, stats)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracks,
+		"albumsInfo": func(w http.ResponseWriter, r *http.Request) {
+			stats.begin()
+			defer stats.end()
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write([]byte(`[{"albumId":"1"}]`))
+		},
+		"radio/getStation": func(w http.ResponseWriter, r *http.Request) {
+			stats.begin()
+			defer stats.end()
+			w.Write([]byte(`{"id":"a1"}`))
+		},
+	})
+	c.Option(ResponseCache(NewMemoryCache(10, 0)))
+
+	for i := 0; i < 3; i++ {
+		ts, err := c.TracksInfo(1, 2)
+		if err != nil || len(ts) != 2 {
+			t.Fatalf("%d. unexpected result %v, %v", i, ts, err)
+		}
+	}
+	if stats.calls != 1 {
+		t.Errorf("expected cacheable calls to be made once got %d", stats.calls)
+	}
+
+	// The order of the ids doesn't matter
+	ts, err := c.TracksInfo(2, 1)
+	if err != nil || len(ts) != 2 || ts[0].Id != 2 {
+		t.Fatalf("unexpected result %v, %v", ts, err)
+	}
+	if stats.calls != 1 {
+		t.Errorf("expected reordered ids to be served from the cache got %d calls", stats.calls)
+	}
+
+	// Different arguments are cached separately
+	c.TracksInfo(1, 3)
+	if stats.calls != 2 {
+		t.Errorf("expected a new call for new arguments got %d", stats.calls)
+	}
+
+	stats.calls = 0
+	for i := 0; i < 2; i++ {
+		c.AlbumsInfo(1)
+		c.GetStation("a1")
+	}
+	if stats.calls != 4 {
+		t.Errorf("expected no-store and uncacheable methods to bypass the cache got %d calls", stats.calls)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	tests := []struct {
+		url      string
+		hostName string
+		want     string
+	}{
+		{"http://a.example.com/api/0.1/tracksInfo?ids=3,1,2&detail=full", "", "http://a.example.com/api/0.1/tracksInfo?detail=full&ids=1%2C2%2C3"},
+		{"http://a.example.com/api/0.1/tracksInfo?versionIds=9,8", "", "http://a.example.com/api/0.1/tracksInfo?versionIds=8%2C9"},
+		{"https://a.example.com/api/0.2/albumsInfo?ids=1", "", "https://a.example.com/api/0.2/albumsInfo?ids=1"},
+		{"http://10.0.0.1/api/0.1/albumsInfo?ids=1", "b.example.com", "b.example.com http://10.0.0.1/api/0.1/albumsInfo?ids=1"},
+	}
+	for i, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k := cacheKey(u, test.hostName); k != test.want {
+			t.Errorf("%d. expected %q got %q", i, test.want, k)
+		}
+	}
+}
+
+func TestClientCacheShared(t *testing.T) {
+	cache := NewMemoryCache(10, 0)
+
+	var clients []*Client
+	for _, name := range []string{"Live", "Staging"} {
+		name := name
+		c := newTestClient(t, map[string]http.HandlerFunc{
+			"artistsInfo": func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[{"artistId":"9","artistName":"` + name + `"}]`))
+			},
+		})
+		c.Option(ResponseCache(cache))
+		clients = append(clients, c)
+	}
+
+	for i := 0; i < 2; i++ {
+		for j, want := range []string{"Live", "Staging"} {
+			as, err := clients[j].ArtistsInfo(9)
+			if err != nil || len(as) != 1 || as[0].Name != want {
+				t.Errorf("%d. expected %s got %v, %v", i, want, as, err)
+			}
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected each server's response to be cached separately got %d entries", cache.Len())
+	}
+}
+
+func TestClientCacheRevalidate(t *testing.T) {
+	var calls, notModified int
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"artistsInfo": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "max-age=0")
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte(`[{"artistId":"9","artistName":"Jimi Hendrix"}]`))
+		},
+	})
+	c.Option(ResponseCache(NewMemoryCache(10, 0)))
+
+	for i := 0; i < 3; i++ {
+		as, err := c.ArtistsInfo(9)
+		if err != nil || len(as) != 1 || as[0].Name != "Jimi Hendrix" {
+			t.Fatalf("%d. unexpected result %v, %v", i, as, err)
+		}
+	}
+	if calls != 3 || notModified != 2 {
+		t.Errorf("expected 3 calls, 2 revalidated, got %d calls, %d revalidated", calls, notModified)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/we7/go-mediagraft/pkg/mediagraft/oauth"
 )
@@ -26,6 +27,14 @@ type Client struct {
 
 	verbosity   int
 	oauthClient *oauth.Client
+
+	lookupChunkSize   int
+	lookupConcurrency int
+
+	cache    Cache
+	cacheTTL time.Duration
+	drift    *DriftRecorder
+	format   WireFormat
 }
 
 var DefaultClient = &Client{
@@ -41,14 +50,36 @@ var DefaultClient = &Client{
 
 	oauthClient: oauth.DefaultClient,
 	verbosity:   0,
+
+	lookupChunkSize:   DefaultLookupChunkSize,
+	lookupConcurrency: DefaultLookupConcurrency,
+
+	cacheTTL: DefaultCacheTTL,
+	format:   JSON,
 }
 
-// New returns a new oauth client with the default settings
-func New() *Client {
+// New returns a new client with the default settings and the options
+// applied. Unless the OAuthClient option is given each client has its
+// own oauth.Client, so credentials are never shared between clients.
+func New(opts ...option) *Client {
 	c := *DefaultClient
+	c.oauthClient = oauth.New()
+	c.Option(opts...)
 	return &c
 }
 
+// Clone returns a deep copy of the client, including its oauth.Client.
+// The ResponseCache and Drift recorder are not copied: the clone shares
+// them with the original, they are both safe for concurrent use. Set
+// them on the clone to give it its own.
+func (c *Client) Clone() *Client {
+	n := *c
+	if c.oauthClient != nil {
+		n.oauthClient = c.oauthClient.Clone()
+	}
+	return &n
+}
+
 type option func(c *Client) option
 
 // Option sets the options specified.
@@ -73,6 +104,24 @@ func (c *Client) Verbosity() int {
 	return c.verbosity
 }
 
+// Host sets the host:port API calls are sent to
+func Host(h string) option {
+	return func(c *Client) option {
+		previous := c.Host
+		c.Host = h
+		return Host(previous)
+	}
+}
+
+// ApiKey sets the API key sent with every call
+func ApiKey(k string) option {
+	return func(c *Client) option {
+		previous := c.ApiKey
+		c.ApiKey = k
+		return ApiKey(previous)
+	}
+}
+
 // OAuthClient sets the underlying oauth.Clinet we'll be using
 func OAuthClient(o *oauth.Client) option {
 	return func(c *Client) option {
@@ -103,7 +152,7 @@ func (c *Client) Call(httpmethod string, method string, vs *url.Values, body io.
 
 	vs.Set("apiKey", c.ApiKey)
 	vs.Set("appVersion", c.AppVersion)
-	vs.Set("format", "json")
+	vs.Set("format", string(c.ResponseFormat()))
 
 	u.RawQuery = vs.Encode()
 
@@ -118,5 +167,9 @@ func (c *Client) Call(httpmethod string, method string, vs *url.Values, body io.
 		r.Host = c.HostName
 	}
 
+	if c.cache != nil && httpmethod == "GET" && CacheableMethods[method] {
+		return c.cachedDo(cacheKey(u, c.HostName), r)
+	}
+
 	return c.OAuthClient().Do(r)
 }
@@ -1 +1,58 @@
 package mediagraft
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/we7/go-mediagraft/pkg/mediagraft/oauth"
+)
+
+func TestNewIsolated(t *testing.T) {
+	a := New(Host("a.example.com"), ApiKey("a"), Verbosity(1))
+	b := New()
+
+	if a.Host != "a.example.com" || a.ApiKey != "a" || a.Verbosity() != 1 {
+		t.Errorf("options were not applied at construction: %+v", a)
+	}
+	if b.Host != DefaultClient.Host || b.ApiKey != DefaultClient.ApiKey || b.Verbosity() != 0 {
+		t.Errorf("options leaked between clients: %+v", b)
+	}
+	if a.OAuthClient() == b.OAuthClient() || a.OAuthClient() == DefaultClient.OAuthClient() {
+		t.Errorf("expected each client to have its own oauth.Client")
+	}
+
+	o := oauth.New()
+	if c := New(OAuthClient(o)); c.OAuthClient() != o {
+		t.Errorf("OAuthClient option was not applied")
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := New(Host("a.example.com"))
+	n := c.Clone()
+	n.Option(Host("b.example.com"))
+
+	if c.Host != "a.example.com" || n.Host != "b.example.com" {
+		t.Errorf("changing the clone changed the original")
+	}
+	if c.OAuthClient() == n.OAuthClient() {
+		t.Errorf("expected the clone to have its own oauth.Client")
+	}
+}
+
+// TestConcurrentNew is only meaningful with -race
+func TestConcurrentNew(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := New(ApiKey("k"))
+			if err := c.OAuthClient().AddDomain(c.Host, oauth.DefaultCredentials()); err != nil {
+				t.Error(err)
+			}
+			c.Clone().Option(Verbosity(2))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,82 @@
+package mediagraft
+
+import "encoding/json"
+
+// The server sends the artist and album of tracks and albums as fields
+// inline in the object, these methods map them to and from the
+// ArtistRef and AlbumRef fields.
+
+func (t *Track) UnmarshalJSON(b []byte) error {
+	type track Track
+	v := struct {
+		*track
+		trackDurations
+		*ArtistRef
+		*AlbumRef
+	}{track: (*track)(t), trackDurations: newTrackDurations()}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	t.Duration = v.value()
+	t.Artist = v.ArtistRef
+	t.Album = v.AlbumRef
+	return nil
+}
+
+func (t Track) MarshalJSON() ([]byte, error) {
+	type track Track
+	return json.Marshal(struct {
+		track
+		Duration string `json:"duration"`
+		*ArtistRef
+		*AlbumRef
+	}{track(t), formatSeconds(t.Duration), t.Artist, t.Album})
+}
+
+func (t *TrackVersion) UnmarshalJSON(b []byte) error {
+	type trackVersion TrackVersion
+	v := struct {
+		*trackVersion
+		trackDurations
+		*ArtistRef
+	}{trackVersion: (*trackVersion)(t), trackDurations: newTrackDurations()}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	t.Duration = v.value()
+	t.Artist = v.ArtistRef
+	return nil
+}
+
+func (t TrackVersion) MarshalJSON() ([]byte, error) {
+	type trackVersion TrackVersion
+	return json.Marshal(struct {
+		trackVersion
+		Duration string `json:"duration"`
+		*ArtistRef
+	}{trackVersion(t), formatSeconds(t.Duration), t.Artist})
+}
+
+func (a *Album) UnmarshalJSON(b []byte) error {
+	type album Album
+	v := struct {
+		*album
+		*ArtistRef
+	}{album: (*album)(a)}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	a.Artist = v.ArtistRef
+	return nil
+}
+
+func (a Album) MarshalJSON() ([]byte, error) {
+	type album Album
+	return json.Marshal(struct {
+		album
+		*ArtistRef
+	}{album(a), a.Artist})
+}
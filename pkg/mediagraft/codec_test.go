@@ -0,0 +1,83 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAlbumFixture(t *testing.T) {
+	b, err := os.ReadFile("testdata/albumsInfo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var as []Album
+	if err := json.Unmarshal(b, &as); err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 1 || len(as[0].Tracks) != 1 {
+		t.Fatalf("unexpected albums %+v", as)
+	}
+	a, tr := as[0], as[0].Tracks[0]
+
+	if a.Id != 500 || a.Title != "Are You Experienced" || !a.Streamable || a.ComposerID != 43 {
+		t.Errorf("album fields not decoded: %+v", a)
+	}
+	if !reflect.DeepEqual(a.Artist, &ArtistRef{42, "Jimi Hendrix", "The Jimi Hendrix Experience"}) {
+		t.Errorf("album artist not decoded: %+v", a.Artist)
+	}
+	if len(a.Genres) != 1 || a.Genres[0].Name != "Rock" {
+		t.Errorf("album genres not decoded: %+v", a.Genres)
+	}
+	if a.IsAlikeTitleMatch || !a.IsAlikeArtistMatch {
+		t.Errorf("album match flags not decoded independently: %+v", a)
+	}
+
+	// These fields used to be shadowed by the embedded Artist and Album
+	if tr.Id != 1 || tr.Streamable || tr.Images["100x100"] == "" || tr.Duration != 171*time.Second {
+		t.Errorf("track fields not decoded: %+v", tr)
+	}
+	if len(tr.Genres) != 1 || tr.Genres[0].Name != "Psychedelic" {
+		t.Errorf("track genres not decoded: %+v", tr.Genres)
+	}
+	if tr.ArtistName() != "Jimi Hendrix" || tr.Artist.Id != 42 {
+		t.Errorf("track artist not decoded: %+v", tr.Artist)
+	}
+	if tr.AlbumTitle() != "Are You Experienced" || tr.Album.Id != 500 {
+		t.Errorf("track album not decoded: %+v", tr.Album)
+	}
+	if !tr.IsAlikeTitleMatch || tr.IsAlikeArtistMatch {
+		t.Errorf("track match flags not decoded independently: %+v", tr)
+	}
+
+	out, err := json.Marshal(as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again []Album
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(as, again) {
+		t.Errorf("albums did not round trip:\n%+v\n%+v", as, again)
+	}
+}
+
+func TestTrackWithoutRefs(t *testing.T) {
+	var tr Track
+	if err := json.Unmarshal([]byte(`{"trackId": "3"}`), &tr); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Artist != nil || tr.Album != nil {
+		t.Errorf("expected no artist or album got %+v, %+v", tr.Artist, tr.Album)
+	}
+	if tr.ArtistName() != "" || tr.AlbumTitle() != "" {
+		t.Errorf("expected empty names for missing refs")
+	}
+	if a := tr.Artist.Artist(); a.Id != 0 {
+		t.Errorf("expected a zero Artist from a nil ref got %+v", a)
+	}
+}
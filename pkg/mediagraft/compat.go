@@ -0,0 +1,68 @@
+package mediagraft
+
+// Track, TrackVersion and Album used to embed Artist and Album, which
+// made fields like Id, Images and Genres collide. These helpers ease
+// moving code that relied on the promoted fields to the explicit
+// ArtistRef and AlbumRef fields.
+
+// ArtistName returns the name of the track's artist, or "" if unknown.
+// It replaces the promoted Track.Name field.
+func (t *Track) ArtistName() string {
+	return t.Artist.name()
+}
+
+// AlbumTitle returns the title of the track's album, or "" if unknown.
+// Track.Album.Title still compiles but Album is now a pointer, which is
+// nil when the server sends no album fields, so AlbumTitle is the safe
+// replacement for reading it through the embedded Album.
+func (t *Track) AlbumTitle() string {
+	if t.Album == nil {
+		return ""
+	}
+	return t.Album.Title
+}
+
+// ArtistName returns the name of the version's artist, or "" if unknown
+func (t *TrackVersion) ArtistName() string {
+	return t.Artist.name()
+}
+
+// ArtistName returns the name of the album's artist, or "" if unknown
+func (a *Album) ArtistName() string {
+	return a.Artist.name()
+}
+
+func (r *ArtistRef) name() string {
+	if r == nil {
+		return ""
+	}
+	return r.Name
+}
+
+// Ref returns a reference to the artist
+func (a *Artist) Ref() *ArtistRef {
+	return &ArtistRef{Id: a.Id, Name: a.Name, DisplayName: a.DisplayName}
+}
+
+// Ref returns a reference to the album
+func (a *Album) Ref() *AlbumRef {
+	return &AlbumRef{Id: a.Id, Title: a.Title}
+}
+
+// Artist returns an Artist holding just the referenced fields, for
+// code expecting the previously embedded Artist struct
+func (r *ArtistRef) Artist() Artist {
+	if r == nil {
+		return Artist{}
+	}
+	return Artist{Id: r.Id, Name: r.Name, DisplayName: r.DisplayName}
+}
+
+// Album returns an Album holding just the referenced fields, for code
+// expecting the previously embedded Album struct
+func (r *AlbumRef) Album() Album {
+	if r == nil {
+		return Album{}
+	}
+	return Album{Id: r.Id, Title: r.Title}
+}
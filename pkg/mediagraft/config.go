@@ -1,12 +1,277 @@
 package mediagraft
 
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/we7/go-mediagraft/pkg/mediagraft/oauth"
+)
+
+var (
+	ErrUnknownEnvironment = errors.New("Unknown environment")
+	ErrMissingClientID    = errors.New("A client secret was given without a client ID")
+	ErrMissingSecret      = errors.New("A client ID was given without a client secret")
+	ErrMissingPassword    = errors.New("A username was given without a password")
+	ErrMissingUsername    = errors.New("A password was given without a username")
+	ErrUnknownConfigType  = errors.New("Unknown config file type, expected .json, .yaml, .yml or .toml")
+	ErrNestedConfig       = errors.New("Only flat key/value YAML and TOML config files are supported")
+)
+
 // Config provides the clients configuration details
 type Config struct {
-	Environmment string
-	ID           string
-	Secret       string
-	Key          string
-	Username     string
-	Password     string
-	CheckActive  bool
+	Environment string `json:"environment"` // One of Environments, defaults to production
+	ID          string `json:"id"`          // The oauth client ID
+	Secret      string `json:"secret"`      // The oauth client secret
+	Key         string `json:"key"`         // The API key, defaults to DefaultClient's
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	CheckActive bool   `json:"checkActive"` // Only allow activated user accounts to log in
+}
+
+// Environment describes where a Mediagraft deployment is found
+type Environment struct {
+	Proto      string // The protocol for API calls
+	Host       string // The host:port serving the API
+	ApiBase    string
+	ApiVersion string
+	AuthProto  string // The protocol for oauth token requests
+}
+
+// DefaultEnvironment is used when Config.Environment is empty
+const DefaultEnvironment = "production"
+
+// Environments are the named Mediagraft deployments
+var Environments = map[string]Environment{
+	"production": {
+		Proto:      "http",
+		Host:       "api.we7.com",
+		ApiBase:    "/api",
+		ApiVersion: "0.1",
+		AuthProto:  "https",
+	},
+	"staging": {
+		Proto:      "http",
+		Host:       "api.stagingf.we7.com",
+		ApiBase:    "/api",
+		ApiVersion: "0.1",
+		AuthProto:  "https",
+	},
+	"local": {
+		Proto:      "http",
+		Host:       "localhost:8080",
+		ApiBase:    "/api",
+		ApiVersion: "0.1",
+		AuthProto:  "http",
+	},
+}
+
+// Validate checks the config is usable
+func (cfg Config) Validate() error {
+	if _, err := cfg.environment(); err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.ID == "" && cfg.Secret != "":
+		return ErrMissingClientID
+	case cfg.ID != "" && cfg.Secret == "":
+		return ErrMissingSecret
+	case cfg.Username != "" && cfg.Password == "":
+		return ErrMissingPassword
+	case cfg.Username == "" && cfg.Password != "":
+		return ErrMissingUsername
+	}
+
+	return nil
+}
+
+func (cfg Config) environment() (Environment, error) {
+	name := cfg.Environment
+	if name == "" {
+		name = DefaultEnvironment
+	}
+	env, ok := Environments[name]
+	if !ok {
+		return Environment{}, fmt.Errorf("%w %q", ErrUnknownEnvironment, name)
+	}
+	return env, nil
+}
+
+// NewFromConfig returns a new client for the config's environment,
+// with its own oauth client holding the config's credentials
+func NewFromConfig(cfg Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	env, _ := cfg.environment()
+
+	c := New(Host(env.Host))
+	c.Proto = env.Proto
+	c.ApiBase = env.ApiBase
+	c.ApiVersion = env.ApiVersion
+	if cfg.Key != "" {
+		c.Option(ApiKey(cfg.Key))
+	}
+
+	oc := c.OAuthClient()
+	if cfg.ID != "" {
+		creds := oauth.DefaultCredentials()
+		creds.Proto = env.AuthProto
+		creds.ClientID = cfg.ID
+		creds.ClientSecret = cfg.Secret
+		creds.ApiKey = c.ApiKey
+		creds.Username = cfg.Username
+		creds.Password = cfg.Password
+		creds.CheckEnabled = cfg.CheckActive
+
+		if err := oc.AddDomain(env.Host, creds); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// ConfigFromEnv reads a config from the MEDIAGRAFT_ENVIRONMENT,
+// MEDIAGRAFT_API_KEY, MEDIAGRAFT_CHECK_ACTIVE, OAUTH_CLIENT_ID,
+// OAUTH_CLIENT_SECRET, OAUTH_USERNAME and OAUTH_PASSWORD environment
+// variables
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Environment: os.Getenv("MEDIAGRAFT_ENVIRONMENT"),
+		ID:          os.Getenv("OAUTH_CLIENT_ID"),
+		Secret:      os.Getenv("OAUTH_CLIENT_SECRET"),
+		Key:         os.Getenv("MEDIAGRAFT_API_KEY"),
+		Username:    os.Getenv("OAUTH_USERNAME"),
+		Password:    os.Getenv("OAUTH_PASSWORD"),
+	}
+
+	if v := os.Getenv("MEDIAGRAFT_CHECK_ACTIVE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("MEDIAGRAFT_CHECK_ACTIVE: %v", err)
+		}
+		cfg.CheckActive = b
+	}
+
+	return cfg, nil
+}
+
+// LoadConfig reads a config file, the format is chosen by the file
+// extension. JSON files are decoded in full. YAML and TOML files are
+// not parsed in full: only a flat subset is read, one top level
+// "key: value" or "key = value" per line using the JSON field names,
+// with single or double quoted or bare scalar values and # comments.
+// Anything nested, such as indented mappings, TOML tables, lists,
+// arrays, inline tables and block scalars, fails with ErrNestedConfig.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&cfg)
+	case ".yaml", ".yml":
+		err = cfg.decodeFlat(bufio.NewScanner(f), ":")
+	case ".toml":
+		err = cfg.decodeFlat(bufio.NewScanner(f), "=")
+	default:
+		err = ErrUnknownConfigType
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// decodeFlat decodes the key/value lines of a flat YAML or TOML file
+func (cfg *Config) decodeFlat(s *bufio.Scanner, sep string) error {
+	for n := 1; s.Scan(); n++ {
+		raw := s.Text()
+		l := strings.TrimSpace(raw)
+		if l == "" || strings.HasPrefix(l, "#") || l == "---" {
+			continue
+		}
+		if raw[0] == ' ' || raw[0] == '\t' || strings.HasPrefix(l, "[") || strings.HasPrefix(l, "- ") {
+			return fmt.Errorf("line %d: %w", n, ErrNestedConfig)
+		}
+
+		i := strings.Index(l, sep)
+		if i < 0 {
+			return fmt.Errorf("line %d: expected key%svalue", n, sep)
+		}
+		k := strings.TrimSpace(l[:i])
+		v := strings.TrimSpace(l[i+1:])
+		if v == "" || strings.HasPrefix(v, "#") || strings.ContainsAny(v[:1], "[{|>") {
+			return fmt.Errorf("line %d: %s: %w", n, k, ErrNestedConfig)
+		}
+		v, err := flatValue(v)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+
+		switch k {
+		case "environment":
+			cfg.Environment = v
+		case "id":
+			cfg.ID = v
+		case "secret":
+			cfg.Secret = v
+		case "key":
+			cfg.Key = v
+		case "username":
+			cfg.Username = v
+		case "password":
+			cfg.Password = v
+		case "checkActive":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("line %d: checkActive: %v", n, err)
+			}
+			cfg.CheckActive = b
+		default:
+			return fmt.Errorf("line %d: unknown key %q", n, k)
+		}
+	}
+
+	return s.Err()
+}
+
+// flatValue unquotes a scalar value, stripping any trailing comment
+func flatValue(v string) (string, error) {
+	if q := v[0]; q == '"' || q == '\'' {
+		end := 1
+		for ; end < len(v) && v[end] != q; end++ {
+			if q == '"' && v[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(v) {
+			return "", fmt.Errorf("unterminated string %s", v)
+		}
+		if rest := strings.TrimSpace(v[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string %s", rest, v[:end+1])
+		}
+		if q == '\'' {
+			return v[1:end], nil
+		}
+		return strconv.Unquote(v[:end+1])
+	}
+
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v), nil
 }
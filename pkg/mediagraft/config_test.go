@@ -1 +1,150 @@
 package mediagraft
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testConfigs = []struct {
+	cfg Config
+	err error
+}{
+	{Config{}, nil},
+	{Config{Environment: "staging", ID: "id", Secret: "s", Username: "u", Password: "p"}, nil},
+	{Config{Environment: "qa"}, ErrUnknownEnvironment},
+	{Config{ID: "id"}, ErrMissingSecret},
+	{Config{Secret: "s"}, ErrMissingClientID},
+	{Config{ID: "id", Secret: "s", Username: "u"}, ErrMissingPassword},
+	{Config{ID: "id", Secret: "s", Password: "p"}, ErrMissingUsername},
+}
+
+func TestConfigValidate(t *testing.T) {
+	for i, tt := range testConfigs {
+		if err := tt.cfg.Validate(); !errors.Is(err, tt.err) {
+			t.Errorf("%d. expected %v got %v", i, tt.err, err)
+		}
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	c, err := NewFromConfig(Config{Environment: "staging", ID: "id", Secret: "s", Key: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := Environments["staging"]
+	if c.Proto != env.Proto || c.Host != env.Host || c.ApiBase != env.ApiBase || c.ApiVersion != env.ApiVersion {
+		t.Errorf("client not configured for staging: %+v", c)
+	}
+	if c.ApiKey != "k" {
+		t.Errorf("expected api key k got %s", c.ApiKey)
+	}
+	if c.OAuthClient() == DefaultClient.OAuthClient() {
+		t.Errorf("expected a dedicated oauth client")
+	}
+
+	// Requests to a host without credentials are not signed, so a
+	// signature proves the credentials were registered for the host
+	r, _ := http.NewRequest("GET", "http://"+env.Host+"/api/0.1/tracksInfo", nil)
+	if sig, _ := DefaultClient.OAuthClient().Sign(r); sig != nil {
+		t.Errorf("credentials leaked into the default oauth client")
+	}
+}
+
+func writeTestConfig(t *testing.T, name, s string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var testConfigFiles = []struct {
+	name string
+	body string
+}{
+	{"config.json", `{"environment": "staging", "id": "myid", "secret": "my secret", "key": "k", "username": "u@example.com", "password": "p#1", "checkActive": true}`},
+	{"config.yaml", "---\n# staging\nenvironment: staging\nid: myid\nsecret: \"my secret\"\nkey: k # the api key\nusername: u@example.com\npassword: 'p#1'\ncheckActive: true\n"},
+	{"config.toml", "# staging\nenvironment = \"staging\"\nid = \"myid\"\nsecret = \"my secret\"\nkey = \"k\"\nusername = \"u@example.com\"\npassword = \"p#1\" # quoted\ncheckActive = true\n"},
+}
+
+func TestLoadConfig(t *testing.T) {
+	want := Config{
+		Environment: "staging",
+		ID:          "myid",
+		Secret:      "my secret",
+		Key:         "k",
+		Username:    "u@example.com",
+		Password:    "p#1",
+		CheckActive: true,
+	}
+
+	for i, tt := range testConfigFiles {
+		cfg, err := LoadConfig(writeTestConfig(t, tt.name, tt.body))
+		if err != nil {
+			t.Errorf("%d. %s: %v", i, tt.name, err)
+			continue
+		}
+		if cfg != want {
+			t.Errorf("%d. %s: expected %+v got %+v", i, tt.name, want, cfg)
+		}
+	}
+
+	if _, err := LoadConfig(writeTestConfig(t, "config.ini", "")); !errors.Is(err, ErrUnknownConfigType) {
+		t.Errorf("expected %v got %v", ErrUnknownConfigType, err)
+	}
+	if _, err := LoadConfig(writeTestConfig(t, "bad.yaml", "colour: blue\n")); err == nil {
+		t.Errorf("expected an error for an unknown key")
+	}
+	if _, err := LoadConfig(writeTestConfig(t, "bad.toml", "password = \"p\" junk\n")); err == nil {
+		t.Errorf("expected an error for text after a quoted value")
+	}
+	cfg, err := LoadConfig(writeTestConfig(t, "quotes.toml", "password = \"p\\\"#1\" # it's \"quoted\"\n"))
+	if err != nil || cfg.Password != `p"#1` {
+		t.Errorf("expected an escaped quote in the password got %q, %v", cfg.Password, err)
+	}
+}
+
+func TestLoadConfigNested(t *testing.T) {
+	for i, tt := range []struct{ name, body string }{
+		{"nested.yaml", "environment: staging\noauth:\n  id: myid\n"},
+		{"list.yaml", "environment:\n- staging\n"},
+		{"flow.yaml", "oauth: {id: myid}\n"},
+		{"block.yaml", "secret: |\n  s\n"},
+		{"table.toml", "environment = \"staging\"\n[oauth]\nid = \"myid\"\n"},
+		{"array.toml", "environment = [\"staging\"]\n"},
+		{"inline.toml", "oauth = { id = \"myid\" }\n"},
+	} {
+		_, err := LoadConfig(writeTestConfig(t, tt.name, tt.body))
+		if !errors.Is(err, ErrNestedConfig) {
+			t.Errorf("%d. %s: expected %v got %v", i, tt.name, ErrNestedConfig, err)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("MEDIAGRAFT_ENVIRONMENT", "local")
+	t.Setenv("MEDIAGRAFT_API_KEY", "k")
+	t.Setenv("MEDIAGRAFT_CHECK_ACTIVE", "true")
+	t.Setenv("OAUTH_CLIENT_ID", "id")
+	t.Setenv("OAUTH_CLIENT_SECRET", "s")
+	t.Setenv("OAUTH_USERNAME", "u")
+	t.Setenv("OAUTH_PASSWORD", "p")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{"local", "id", "s", "k", "u", "p", true}
+	if cfg != want {
+		t.Errorf("expected %+v got %+v", want, cfg)
+	}
+
+	t.Setenv("MEDIAGRAFT_CHECK_ACTIVE", "sometimes")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Errorf("expected an error for a bad MEDIAGRAFT_CHECK_ACTIVE")
+	}
+}
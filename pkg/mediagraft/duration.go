@@ -0,0 +1,95 @@
+package mediagraft
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonDuration decodes a duration sent as a JSON number or a string,
+// in units of unit. Strings may also be in [h:]m:ss form.
+type jsonDuration struct {
+	unit time.Duration
+	d    time.Duration
+	set  bool
+}
+
+func (j *jsonDuration) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	s := string(b)
+	if strings.HasPrefix(s, `"`) {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return err
+		}
+		s = strings.TrimSpace(s)
+	}
+	if s == "" {
+		return nil
+	}
+
+	d, err := parseDuration(s, j.unit)
+	if err != nil {
+		return err
+	}
+	j.d, j.set = d, true
+	return nil
+}
+
+// parseDuration parses a number of units, or an [h:]m:ss clock time
+func parseDuration(s string, unit time.Duration) (time.Duration, error) {
+	if strings.Contains(s, ":") {
+		var d time.Duration
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		for _, p := range parts {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			d = d*60 + time.Duration(v*float64(time.Second))
+		}
+		return d, nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return time.Duration(v * float64(unit)), nil
+}
+
+// formatSeconds formats d as a string number of seconds, as the
+// server sends them
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// trackDurations holds the duration fields the server may send, the
+// millisecond form takes precedence
+type trackDurations struct {
+	Duration   jsonDuration `json:"duration"`
+	DurationMs jsonDuration `json:"durationMs"`
+}
+
+func newTrackDurations() trackDurations {
+	return trackDurations{
+		Duration:   jsonDuration{unit: time.Second},
+		DurationMs: jsonDuration{unit: time.Millisecond},
+	}
+}
+
+func (t trackDurations) value() time.Duration {
+	if t.DurationMs.set {
+		return t.DurationMs.d
+	}
+	return t.Duration.d
+}
@@ -0,0 +1,87 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTrackDurationFixture(t *testing.T) {
+	b, err := os.ReadFile("testdata/tracksInfo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ts []Track
+	if err := json.Unmarshal(b, &ts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []time.Duration{
+		171 * time.Second,
+		210 * time.Second,
+		145*time.Second + 500*time.Millisecond,
+		5*time.Minute + 13*time.Second,
+		240*time.Second + 800*time.Millisecond,
+		199 * time.Second,
+		0,
+		0,
+		time.Hour + 2*time.Minute + 3*time.Second,
+	}
+	if len(ts) != len(want) {
+		t.Fatalf("expected %d tracks got %d", len(want), len(ts))
+	}
+	for i, tr := range ts {
+		if tr.Duration != want[i] {
+			t.Errorf("%d. %s: expected %v got %v", i, tr.Title, want[i], tr.Duration)
+		}
+	}
+
+	if ts[0].Id != 1 || ts[0].Title != "Purple Haze" || !ts[0].Streamable || ts[0].TrackVersionId != 11 {
+		t.Errorf("other fields were not decoded: %+v", ts[0])
+	}
+}
+
+func TestTrackDurationRoundTrip(t *testing.T) {
+	in := Track{Id: 1, Title: "Purple Haze", Duration: 171500 * time.Millisecond}
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out Track
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Id != in.Id || out.Title != in.Title || out.Duration != in.Duration {
+		t.Errorf("expected %+v got %+v from %s", in, out, b)
+	}
+
+	v := TrackVersion{Id: 2, Title: "Live", Duration: 3 * time.Minute}
+	if b, err = json.Marshal(v); err != nil {
+		t.Fatal(err)
+	}
+	var vout TrackVersion
+	if err := json.Unmarshal(b, &vout); err != nil {
+		t.Fatal(err)
+	}
+	if vout.Id != v.Id || vout.Title != v.Title || vout.Duration != v.Duration {
+		t.Errorf("expected %+v got %+v from %s", v, vout, b)
+	}
+}
+
+func TestTrackDurationInvalid(t *testing.T) {
+	for i, s := range []string{
+		`{"duration": "three minutes"}`,
+		`{"duration": "1:2:3:4"}`,
+		`{"duration": "-1:00"}`,
+		`{"duration": true}`,
+	} {
+		var tr Track
+		if err := json.Unmarshal([]byte(s), &tr); err == nil {
+			t.Errorf("%d. expected an error for %s", i, s)
+		}
+	}
+}
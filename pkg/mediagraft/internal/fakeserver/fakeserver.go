@@ -0,0 +1,28 @@
+// Package fakeserver routes API calls to handlers, for the fake servers
+// used by the mediagraft tests and the mediagrafttest package.
+package fakeserver
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Handler returns a handler calling the handler for the API method of
+// each request, handlers are keyed by method e.g. "tracksInfo" or
+// "radio/getStation". Other methods are not found.
+func Handler(apiVersion string, handlers map[string]http.HandlerFunc) http.Handler {
+	prefix := "/" + apiVersion + "/"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := strings.Index(r.URL.Path, prefix)
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		h, ok := handlers[r.URL.Path[i+len(prefix):]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	})
+}
@@ -0,0 +1,454 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Iterator decodes the items of an array in one or more responses one
+// at a time, so large responses such as albums with all their tracks
+// needn't be held in memory. It's used like a bufio.Scanner:
+//
+//	it := c.AlbumsInfoIter(ids...)
+//	defer it.Close()
+//	for it.Next() {
+//		a := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	c      *Client
+	method string
+	path   []string
+
+	open   func() (io.ReadCloser, bool, error) // Opens the next response, false when there are none left
+	seen   func(*T)                            // Called with each item, may be nil
+	finish func() error                        // Called after the last item, may be nil
+
+	body  io.ReadCloser
+	items itemReader
+	v     T
+	err   error
+	done  bool
+}
+
+// NewIterator returns an iterator over the items of the array at path
+// in the body of a response to method, e.g. "data", "searchResults",
+// "tracks" for the tracks of a simpleSearchWithInfo response. Without
+// a path the response itself must be an array. A "[]" or "*" segment
+// steps into each element of an array in turn, e.g. "[]", "albums" for
+// the albums of every artist in an artistsInfo response. The iterator
+// closes body when done.
+func NewIterator[T any](c *Client, method string, body io.ReadCloser, path ...string) *Iterator[T] {
+	done := false
+	return &Iterator[T]{
+		c:      c,
+		method: method,
+		path:   path,
+		open: func() (io.ReadCloser, bool, error) {
+			if done {
+				return nil, false, nil
+			}
+			done = true
+			return body, true, nil
+		},
+	}
+}
+
+// Next decodes the next item, returning false when there are no more
+// or an error occurred
+func (it *Iterator[T]) Next() bool {
+	for !it.done {
+		if it.items == nil {
+			body, ok, err := it.open()
+			if err != nil {
+				it.fail(err)
+				return false
+			}
+			if !ok {
+				it.done = true
+				if it.finish != nil {
+					it.err = it.finish()
+				}
+				return false
+			}
+			it.body = body
+			it.items = it.c.itemReader(it.method, body, it.path)
+		}
+
+		var v T
+		ok, err := it.items.next(&v)
+		if err != nil {
+			it.fail(err)
+			return false
+		}
+		if ok {
+			it.v = v
+			if it.seen != nil {
+				it.seen(&it.v)
+			}
+			return true
+		}
+
+		it.body.Close()
+		it.body, it.items = nil, nil
+	}
+	return false
+}
+
+// Value returns the item decoded by the last call to Next
+func (it *Iterator[T]) Value() T {
+	return it.v
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close stops the iteration, closing any open response
+func (it *Iterator[T]) Close() error {
+	it.done = true
+	if it.body == nil {
+		return nil
+	}
+	err := it.body.Close()
+	it.body, it.items = nil, nil
+	return err
+}
+
+func (it *Iterator[T]) fail(err error) {
+	it.err = err
+	it.Close()
+}
+
+// itemReader decodes the items of an array in a response
+type itemReader interface {
+	// next decodes the next item into v, returning false after the
+	// last one
+	next(v interface{}) (bool, error)
+}
+
+func (c *Client) itemReader(method string, r io.Reader, path []string) itemReader {
+	n := &normalizer{method: method, drift: c.drift}
+	itemPath := itemPath(path)
+	if c.ResponseFormat() == XML {
+		n.text = true
+		return &xmlItems{dec: xml.NewDecoder(r), n: n, path: path, itemPath: itemPath}
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &jsonItems{dec: dec, n: n, path: path, itemPath: itemPath}
+}
+
+// isStep returns true if the path segment steps into each element of
+// an array
+func isStep(seg string) bool {
+	return seg == "[]" || seg == "*"
+}
+
+// itemPath returns the path of the items, as recorded by drift, e.g.
+// "[].albums[]" for the path "[]", "albums"
+func itemPath(path []string) string {
+	var b strings.Builder
+	for i, seg := range path {
+		if isStep(seg) {
+			b.WriteString("[]")
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(seg)
+	}
+	b.WriteString("[]")
+	return b.String()
+}
+
+// iterLevel is an array stepped into by a [] segment of an iterator's
+// path, each of its elements is searched for the rest of the path
+type iterLevel struct {
+	rest []string
+	open int // The objects or elements opened within the current element
+}
+
+type jsonItems struct {
+	dec      *json.Decoder
+	n        *normalizer
+	path     []string
+	itemPath string
+	levels   []iterLevel
+	started  bool
+	ended    bool
+}
+
+func (j *jsonItems) next(v interface{}) (bool, error) {
+	if !j.started {
+		j.started = true
+		found, err := j.seek(j.path)
+		if err != nil || !found {
+			j.ended = true
+			return false, err
+		}
+	}
+
+	for !j.ended {
+		if j.dec.More() {
+			var raw interface{}
+			if err := j.dec.Decode(&raw); err != nil {
+				return false, err
+			}
+			return true, normalizeInto(j.n, raw, v, j.itemPath)
+		}
+
+		if _, err := j.dec.Token(); err != nil { // The closing ]
+			return false, err
+		}
+		found, err := j.step()
+		if err != nil {
+			return false, err
+		}
+		j.ended = !found
+	}
+	return false, nil
+}
+
+// seek reads up to the start of the array at path, returning false if
+// the response doesn't have it
+func (j *jsonItems) seek(path []string) (bool, error) {
+	tok, err := j.dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == json.Delim('[') && len(path) == 0 {
+		return true, nil
+	}
+	if tok == json.Delim('[') && isStep(path[0]) {
+		j.levels = append(j.levels, iterLevel{rest: path[1:]})
+		return j.advance()
+	}
+	if tok != json.Delim('{') || len(path) == 0 || isStep(path[0]) {
+		return false, fmt.Errorf("%s: expected an array at %s, got %v", j.n.method, j.itemPath, tok)
+	}
+	if len(j.levels) != 0 {
+		j.levels[len(j.levels)-1].open++
+	}
+
+	for j.dec.More() {
+		tok, err := j.dec.Token()
+		if err != nil {
+			return false, err
+		}
+		if k, _ := tok.(string); strings.EqualFold(k, path[0]) {
+			return j.seek(path[1:])
+		}
+		var skip json.RawMessage
+		if err := j.dec.Decode(&skip); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// step moves on to the array at path in the next element of the
+// innermost level, returning false when there are none left
+func (j *jsonItems) step() (bool, error) {
+	for len(j.levels) != 0 {
+		if err := j.close(); err != nil {
+			return false, err
+		}
+		found, err := j.advance()
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// advance searches the remaining elements of the innermost level for
+// the rest of the path, dropping the level once they are exhausted
+func (j *jsonItems) advance() (bool, error) {
+	i := len(j.levels) - 1
+	for j.dec.More() {
+		found, err := j.seek(j.levels[i].rest)
+		if err != nil || found {
+			return found, err
+		}
+		if err := j.close(); err != nil {
+			return false, err
+		}
+	}
+
+	j.levels = j.levels[:i]
+	_, err := j.dec.Token() // The closing ]
+	return false, err
+}
+
+// close skips the rest of the objects opened within the current
+// element of the innermost level
+func (j *jsonItems) close() error {
+	l := &j.levels[len(j.levels)-1]
+	for ; l.open > 0; l.open-- {
+		for j.dec.More() {
+			if _, err := j.dec.Token(); err != nil {
+				return err
+			}
+			var skip json.RawMessage
+			if err := j.dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+		if _, err := j.dec.Token(); err != nil { // The closing }
+			return err
+		}
+	}
+	return nil
+}
+
+type xmlItems struct {
+	dec      *xml.Decoder
+	n        *normalizer
+	path     []string
+	itemPath string
+	levels   []iterLevel
+	started  bool
+	ended    bool
+}
+
+func (x *xmlItems) next(v interface{}) (bool, error) {
+	if !x.started {
+		x.started = true
+		root, err := nextElement(x.dec)
+		if err == nil && root == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		found := false
+		if err == nil {
+			found, err = x.seek(x.path)
+		}
+		if err != nil || !found {
+			x.ended = true
+			return false, err
+		}
+	}
+
+	for !x.ended {
+		start, err := nextElement(x.dec)
+		if err != nil {
+			return false, err
+		}
+		if start != nil {
+			e, err := parseElement(x.dec, *start)
+			if err != nil {
+				return false, err
+			}
+			return true, normalizeInto(x.n, e.value(reflect.TypeOf(v)), v, x.itemPath)
+		}
+
+		// The element holding the items has ended
+		if len(x.levels) != 0 {
+			x.levels[len(x.levels)-1].open--
+		}
+		found, err := x.step()
+		if err != nil {
+			return false, err
+		}
+		x.ended = !found
+	}
+	return false, nil
+}
+
+// seek reads up to the children of the element at path, below the
+// current element, returning false if the response doesn't have it
+func (x *xmlItems) seek(path []string) (bool, error) {
+	if len(path) == 0 {
+		return true, nil
+	}
+	if isStep(path[0]) {
+		x.levels = append(x.levels, iterLevel{rest: path[1:]})
+		return x.advance()
+	}
+
+	for {
+		start, err := nextElement(x.dec)
+		if err != nil {
+			return false, err
+		}
+		if start == nil {
+			if len(x.levels) != 0 {
+				x.levels[len(x.levels)-1].open--
+			}
+			return false, nil
+		}
+		if strings.EqualFold(start.Name.Local, path[0]) {
+			if len(x.levels) != 0 {
+				x.levels[len(x.levels)-1].open++
+			}
+			return x.seek(path[1:])
+		}
+		if err := x.dec.Skip(); err != nil {
+			return false, err
+		}
+	}
+}
+
+// step moves on to the element at path in the next child of the
+// innermost level, returning false when there are none left
+func (x *xmlItems) step() (bool, error) {
+	for len(x.levels) != 0 {
+		if err := x.close(); err != nil {
+			return false, err
+		}
+		found, err := x.advance()
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// advance searches the remaining children of the innermost level for
+// the rest of the path, dropping the level once they are exhausted
+func (x *xmlItems) advance() (bool, error) {
+	i := len(x.levels) - 1
+	for {
+		start, err := nextElement(x.dec)
+		if err != nil {
+			return false, err
+		}
+		if start == nil {
+			x.levels = x.levels[:i]
+			if i > 0 {
+				x.levels[i-1].open--
+			}
+			return false, nil
+		}
+
+		x.levels[i].open = 1
+		found, err := x.seek(x.levels[i].rest)
+		if err != nil || found {
+			return found, err
+		}
+		if err := x.close(); err != nil {
+			return false, err
+		}
+	}
+}
+
+// close skips the rest of the elements opened within the current child
+// of the innermost level
+func (x *xmlItems) close() error {
+	l := &x.levels[len(x.levels)-1]
+	for ; l.open > 0; l.open-- {
+		if err := x.dec.Skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
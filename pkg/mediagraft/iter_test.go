@@ -0,0 +1,176 @@
+package mediagraft
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTracksInfoIter(t *testing.T) {
+	stats := &lookupStats{}
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracksInfoHandler(t, "ids", map[int]bool{4: true}, 2, stats),
+	})
+	c.Option(LookupChunkSize(2))
+
+	it := c.TracksInfoIter(1, 2, 3, 4, 5)
+	defer it.Close()
+
+	var got []TrackID
+	for it.Next() {
+		got = append(got, it.Value().Id)
+	}
+
+	// The handler returns each chunk's tracks in reverse
+	if want := []TrackID{2, 1, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected tracks %v got %v", want, got)
+	}
+	if stats.calls != 3 {
+		t.Errorf("expected 3 calls got %d", stats.calls)
+	}
+
+	var perr *PartialResultError
+	if !errors.As(it.Err(), &perr) || !reflect.DeepEqual(perr.Missing, []int{4}) {
+		t.Errorf("expected a *PartialResultError for 4 got %v", it.Err())
+	}
+}
+
+func TestAlbumsInfoIterParity(t *testing.T) {
+	for i, f := range []WireFormat{JSON, XML} {
+		c := newTestClient(t, map[string]http.HandlerFunc{
+			"albumsInfo": func(w http.ResponseWriter, r *http.Request) {
+				http.ServeFile(w, r, "testdata/albumsInfo."+string(f))
+			},
+		})
+		c.Option(ResponseFormat(f))
+
+		want, err := c.AlbumsInfo(500)
+		if err != nil {
+			t.Fatalf("%d. %v", i, err)
+		}
+
+		it := c.AlbumsInfoIter(500)
+		var got []Album
+		for it.Next() {
+			got = append(got, it.Value())
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%d. %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d. iterated %+v expected %+v", i, got, want)
+		}
+	}
+}
+
+func TestNewIteratorPath(t *testing.T) {
+	for i, tc := range []struct {
+		format WireFormat
+		body   string
+	}{
+		{JSON, `{"status": "ok", "data": {"info": {"n": 2}, "searchResults": {"tracks": [{"trackId": "1"}, {"trackId": 2}]}}}`},
+		{XML, `<response><status>ok</status><data><info><n>2</n></info><searchResults><tracks><track trackId="1"/><track><trackId>2</trackId></track></tracks></searchResults></data></response>`},
+	} {
+		c := New(ResponseFormat(tc.format))
+		it := NewIterator[Track](c, "simpleSearchWithInfo", io.NopCloser(strings.NewReader(tc.body)), "data", "searchResults", "tracks")
+
+		var got []TrackID
+		for it.Next() {
+			got = append(got, it.Value().Id)
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%d. %v", i, err)
+		}
+		if want := []TrackID{1, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d. expected tracks %v got %v", i, want, got)
+		}
+	}
+}
+
+func TestNewIteratorSteps(t *testing.T) {
+	const (
+		artistsJSON = `[
+			{"artistId": "1", "albums": [{"albumId": "10", "tracks": [{"trackId": "100"}, {"trackId": "101"}]}, {"albumId": "11"}], "genres": []},
+			{"artistId": "2"},
+			{"artistId": "3", "albums": []},
+			{"artistId": "4", "albums": [{"albumId": "40", "tracks": [{"trackId": "400"}]}]}
+		]`
+		artistsXML = `<artists>
+			<artist><artistId>1</artistId><albums><album><albumId>10</albumId><tracks><track trackId="100"/><track trackId="101"/></tracks></album><album albumId="11"/></albums><genres/></artist>
+			<artist artistId="2"/>
+			<artist><artistId>3</artistId><albums/></artist>
+			<artist><albums><album albumId="40"><tracks><track trackId="400"/></tracks></album></albums><artistId>4</artistId></artist>
+		</artists>`
+	)
+
+	for i, tc := range []struct {
+		format WireFormat
+		body   string
+	}{
+		{JSON, artistsJSON},
+		{XML, artistsXML},
+	} {
+		c := New(ResponseFormat(tc.format))
+
+		var albums []AlbumID
+		it := NewIterator[Album](c, "artistsInfo", io.NopCloser(strings.NewReader(tc.body)), "[]", "albums")
+		for it.Next() {
+			albums = append(albums, it.Value().Id)
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%d. %v", i, err)
+		}
+		if want := []AlbumID{10, 11, 40}; !reflect.DeepEqual(albums, want) {
+			t.Errorf("%d. expected albums %v got %v", i, want, albums)
+		}
+
+		var tracks []TrackID
+		it2 := NewIterator[Track](c, "artistsInfo", io.NopCloser(strings.NewReader(tc.body)), "*", "albums", "*", "tracks")
+		for it2.Next() {
+			tracks = append(tracks, it2.Value().Id)
+		}
+		if err := it2.Err(); err != nil {
+			t.Errorf("%d. %v", i, err)
+		}
+		if want := []TrackID{100, 101, 400}; !reflect.DeepEqual(tracks, want) {
+			t.Errorf("%d. expected tracks %v got %v", i, want, tracks)
+		}
+	}
+
+	it := NewIterator[Album](New(), "artistsInfo", io.NopCloser(strings.NewReader(`{"albums": []}`)), "[]", "albums")
+	if it.Next() || it.Err() == nil {
+		t.Error("expected an error stepping into an object")
+	}
+}
+
+func TestItemPath(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{nil, "[]"},
+		{[]string{"data", "searchResults", "tracks"}, "data.searchResults.tracks[]"},
+		{[]string{"[]", "albums"}, "[].albums[]"},
+		{[]string{"*", "albums", "*", "tracks"}, "[].albums[].tracks[]"},
+	}
+	for i, test := range tests {
+		if p := itemPath(test.path); p != test.want {
+			t.Errorf("%d. expected %q got %q", i, test.want, p)
+		}
+	}
+}
+
+func TestNewIteratorMissingPath(t *testing.T) {
+	it := NewIterator[Track](New(), "simpleSearch", io.NopCloser(strings.NewReader(`{"albums": []}`)), "tracks")
+	if it.Next() || it.Err() != nil {
+		t.Errorf("expected no tracks and no error, got %v", it.Err())
+	}
+
+	it = NewIterator[Track](New(), "simpleSearch", io.NopCloser(strings.NewReader(`{"tracks": []}`)))
+	if it.Next() || it.Err() == nil {
+		t.Error("expected an error iterating over an object")
+	}
+}
@@ -0,0 +1,461 @@
+package mediagraft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The server is inconsistent about sending booleans and numbers as
+// JSON strings or native values, and the ",string" struct tags reject
+// the other form. Responses are therefore decoded leniently: the JSON
+// is first normalized against the Go type it is being decoded into,
+// converting values to the form the type expects, and only then
+// decoded with encoding/json. Any unknown fields and conversions can
+// be recorded in a DriftRecorder to spot changes to the API.
+
+// inlineFields lists the structs whose fields the server sends inline
+// in the objects of types with custom UnmarshalJSON methods
+var inlineFields = map[reflect.Type][]reflect.Type{
+	reflect.TypeOf(Track{}):        {reflect.TypeOf(ArtistRef{}), reflect.TypeOf(AlbumRef{}), reflect.TypeOf(trackDurations{})},
+	reflect.TypeOf(TrackVersion{}): {reflect.TypeOf(ArtistRef{}), reflect.TypeOf(trackDurations{})},
+	reflect.TypeOf(Album{}):        {reflect.TypeOf(ArtistRef{})},
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// DriftRecorder records how responses differ from the Go types they
+// are decoded into, per API method. It is safe for concurrent use.
+type DriftRecorder struct {
+	lock      sync.Mutex
+	endpoints map[string]*EndpointDrift
+}
+
+// EndpointDrift counts the differences seen in one method's responses,
+// keyed by the path of the field e.g. "tracks[].trackNumber"
+type EndpointDrift struct {
+	UnknownFields  map[string]int
+	TypeMismatches map[string]int // The key includes the JSON type received and the Go type expected
+}
+
+func NewDriftRecorder() *DriftRecorder {
+	return &DriftRecorder{endpoints: make(map[string]*EndpointDrift)}
+}
+
+// Drift sets the recorder used to record differences between responses
+// and our types. Responses are decoded leniently whether or not this
+// is set.
+func Drift(d *DriftRecorder) option {
+	return func(c *Client) option {
+		previous := c.drift
+		c.drift = d
+		return Drift(previous)
+	}
+}
+
+func (c *Client) Drift() *DriftRecorder {
+	return c.drift
+}
+
+// Report returns a copy of the drift recorded so far, keyed by method
+func (d *DriftRecorder) Report() map[string]EndpointDrift {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	r := make(map[string]EndpointDrift, len(d.endpoints))
+	for m, e := range d.endpoints {
+		c := EndpointDrift{
+			UnknownFields:  make(map[string]int, len(e.UnknownFields)),
+			TypeMismatches: make(map[string]int, len(e.TypeMismatches)),
+		}
+		for k, v := range e.UnknownFields {
+			c.UnknownFields[k] = v
+		}
+		for k, v := range e.TypeMismatches {
+			c.TypeMismatches[k] = v
+		}
+		r[m] = c
+	}
+	return r
+}
+
+// String formats the report one difference per line, sorted
+func (d *DriftRecorder) String() string {
+	var lines []string
+	for m, e := range d.Report() {
+		for k, n := range e.UnknownFields {
+			lines = append(lines, fmt.Sprintf("%s: unknown field %s (%d)", m, k, n))
+		}
+		for k, n := range e.TypeMismatches {
+			lines = append(lines, fmt.Sprintf("%s: type mismatch %s (%d)", m, k, n))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// Reset discards everything recorded so far
+func (d *DriftRecorder) Reset() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.endpoints = make(map[string]*EndpointDrift)
+}
+
+func (d *DriftRecorder) endpoint(method string) *EndpointDrift {
+	e, ok := d.endpoints[method]
+	if !ok {
+		e = &EndpointDrift{
+			UnknownFields:  make(map[string]int),
+			TypeMismatches: make(map[string]int),
+		}
+		d.endpoints[method] = e
+	}
+	return e
+}
+
+func (d *DriftRecorder) unknown(method, path string) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.endpoint(method).UnknownFields[path]++
+}
+
+func (d *DriftRecorder) mismatch(method, path, got string, want reflect.Type) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.endpoint(method).TypeMismatches[fmt.Sprintf("%s: got %s want %s", path, got, want)]++
+}
+
+// decode leniently decodes the response to method from r into v, in
+// the client's wire format
+func (c *Client) decode(method string, r io.Reader, v interface{}) error {
+	if c.ResponseFormat() == XML {
+		return decodeXML(method, r, v, c.drift)
+	}
+	return decodeLenient(method, r, v, c.drift)
+}
+
+func decodeLenient(method string, r io.Reader, v interface{}, d *DriftRecorder) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	return normalizeInto(&normalizer{method: method, drift: d}, raw, v, "")
+}
+
+// normalizeInto normalizes raw, found at path in the response, against
+// the type of v and decodes it into v
+func normalizeInto(n *normalizer, raw interface{}, v interface{}, path string) error {
+	raw, _ = n.value(raw, reflect.TypeOf(v), path, false)
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+type normalizer struct {
+	method string
+	drift  *DriftRecorder
+	text   bool // Every value arrives as a string, as when decoding XML
+}
+
+// value converts v to the form encoding/json expects when decoding
+// into t. It returns false if the value should be dropped, leaving
+// the field zero, because it can't be converted.
+func (n *normalizer) value(v interface{}, t reflect.Type, path string, quoted bool) (interface{}, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if v == nil {
+		return v, true
+	}
+
+	_, inline := inlineFields[t]
+	if !inline && reflect.PtrTo(t).Implements(unmarshalerType) {
+		// Types with their own decoding are left to it
+		return v, true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			n.drift.mismatch(n.method, path, jsonType(v), t)
+			return nil, false
+		}
+		return n.object(m, t, path), true
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v, true
+		}
+		a, ok := v.([]interface{})
+		if !ok {
+			n.drift.mismatch(n.method, path, jsonType(v), t)
+			return nil, false
+		}
+		out := a[:0]
+		for _, e := range a {
+			if e, ok := n.value(e, t.Elem(), path+"[]", false); ok {
+				out = append(out, e)
+			}
+		}
+		return out, true
+
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			n.drift.mismatch(n.method, path, jsonType(v), t)
+			return nil, false
+		}
+		for k, e := range m {
+			if e, ok := n.value(e, t.Elem(), path+"{}", false); ok {
+				m[k] = e
+			} else {
+				delete(m, k)
+			}
+		}
+		return m, true
+
+	case reflect.Bool:
+		b, ok := n.boolean(v, t, path)
+		if !ok {
+			return nil, false
+		}
+		n.form(v, t, path, quoted)
+		if quoted {
+			return strconv.FormatBool(b), true
+		}
+		return b, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		num, ok := n.number(v, t, path)
+		if !ok {
+			return nil, false
+		}
+		if k := t.Kind(); k != reflect.Float32 && k != reflect.Float64 {
+			if num, ok = n.integer(num, t, path); !ok {
+				return nil, false
+			}
+		}
+		n.form(v, t, path, quoted)
+		if quoted {
+			return num.String(), true
+		}
+		return num, true
+
+	case reflect.String:
+		switch s := v.(type) {
+		case string:
+			return s, true
+		case json.Number:
+			n.drift.mismatch(n.method, path, "number", t)
+			return s.String(), true
+		case bool:
+			n.drift.mismatch(n.method, path, "boolean", t)
+			return strconv.FormatBool(s), true
+		}
+		n.drift.mismatch(n.method, path, jsonType(v), t)
+		return nil, false
+	}
+
+	return v, true
+}
+
+// object normalizes the fields of a JSON object decoded into struct t
+func (n *normalizer) object(m map[string]interface{}, t reflect.Type, path string) map[string]interface{} {
+	fields := jsonFields(t)
+	for k, v := range m {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		f, ok := fields[strings.ToLower(k)]
+		if !ok {
+			n.drift.unknown(n.method, p)
+			continue
+		}
+
+		if v, ok := n.value(v, f.typ, p, f.quoted); ok {
+			m[k] = v
+		} else {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+// form records a value that was converted between a JSON string and
+// a native JSON value
+func (n *normalizer) form(v interface{}, t reflect.Type, path string, quoted bool) {
+	if n.text {
+		return
+	}
+	_, isString := v.(string)
+	switch {
+	case quoted && !isString:
+		n.drift.mismatch(n.method, path, jsonType(v), reflect.TypeOf(""))
+	case !quoted && isString:
+		n.drift.mismatch(n.method, path, "string", t)
+	}
+}
+
+func (n *normalizer) boolean(v interface{}, t reflect.Type, path string) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		if b == "" {
+			n.drift.mismatch(n.method, path, "empty string", t)
+			return false, false
+		}
+		if v, err := strconv.ParseBool(b); err == nil {
+			return v, true
+		}
+	case json.Number:
+		if f, err := b.Float64(); err == nil {
+			return f != 0, true
+		}
+	}
+	n.drift.mismatch(n.method, path, jsonType(v), t)
+	return false, false
+}
+
+func (n *normalizer) number(v interface{}, t reflect.Type, path string) (json.Number, bool) {
+	switch num := v.(type) {
+	case json.Number:
+		return num, true
+	case string:
+		s := strings.TrimSpace(num)
+		if s == "" {
+			n.drift.mismatch(n.method, path, "empty string", t)
+			return "", false
+		}
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return json.Number(s), true
+		}
+	case bool:
+		n.drift.mismatch(n.method, path, "boolean", t)
+		if num {
+			return "1", true
+		}
+		return "0", true
+	}
+	n.drift.mismatch(n.method, path, jsonType(v), t)
+	return "", false
+}
+
+// integer checks num is a whole number, converting forms like 3.0 or
+// 1e3 that encoding/json won't decode into an integer
+func (n *normalizer) integer(num json.Number, t reflect.Type, path string) (json.Number, bool) {
+	if _, err := num.Int64(); err == nil {
+		return num, true
+	}
+	f, err := num.Float64()
+	if err != nil || f != float64(int64(f)) {
+		n.drift.mismatch(n.method, path, "non-integer number", t)
+		return "", false
+	}
+	return json.Number(strconv.FormatInt(int64(f), 10)), true
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+type jsonField struct {
+	typ    reflect.Type
+	quoted bool // The field has the ",string" option
+}
+
+var jsonFieldsCache sync.Map // reflect.Type -> map[string]jsonField
+
+// jsonFields returns the fields encoding/json decodes into for struct
+// t, keyed by lower case name, including any inline fields
+func jsonFields(t reflect.Type) map[string]jsonField {
+	if fs, ok := jsonFieldsCache.Load(t); ok {
+		return fs.(map[string]jsonField)
+	}
+
+	fs := make(map[string]jsonField)
+	addJSONFields(fs, t)
+	for _, it := range inlineFields[t] {
+		addJSONFields(fs, it)
+	}
+
+	jsonFieldsCache.Store(t, fs)
+	return fs
+}
+
+// addJSONFields adds the fields of t, then those of its embedded
+// structs, so shallower fields take precedence as they do in
+// encoding/json
+func addJSONFields(fs map[string]jsonField, t reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		key := strings.ToLower(name)
+		if _, ok := fs[key]; !ok {
+			fs[key] = jsonField{typ: ft, quoted: strings.Contains(","+opts+",", ",string,")}
+		}
+	}
+
+	for _, et := range embedded {
+		addJSONFields(fs, et)
+	}
+}
@@ -0,0 +1,125 @@
+package mediagraft
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeLenient(t *testing.T) {
+	for i, s := range []string{
+		`{"trackId": "1", "streamable": "true", "trackNumber": "3", "duration": "60", "artistId": "9"}`,
+		`{"trackId": 1, "streamable": true, "trackNumber": 3, "duration": 60, "artistId": 9}`,
+		`{"trackId": 1.0, "streamable": 1, "trackNumber": "3.0", "duration": 60, "artistId": "9"}`,
+	} {
+		var tr Track
+		if err := decodeLenient("tracksInfo", strings.NewReader(s), &tr, nil); err != nil {
+			t.Errorf("%d. %s: %v", i, s, err)
+			continue
+		}
+		if tr.Id != 1 || !tr.Streamable || tr.TrackNumber != 3 || tr.Duration != time.Minute || tr.Artist.Id != 9 {
+			t.Errorf("%d. %s: decoded %+v", i, s, tr)
+		}
+	}
+}
+
+func TestDecodeLenientStation(t *testing.T) {
+	for i, s := range []string{
+		`{"id": "a1", "searchable": "true", "popularity": "0.5", "explicitCount": 2, "promoted": true}`,
+		`{"id": "a1", "searchable": true, "popularity": 0.5, "explicitCount": "2", "promoted": "true"}`,
+	} {
+		var st Station
+		if err := decodeLenient("radio/getStation", strings.NewReader(s), &st, nil); err != nil {
+			t.Errorf("%d. %s: %v", i, s, err)
+			continue
+		}
+		if st.ID != "a1" || !st.Searchable || st.Popularity != 0.5 || st.ExplicitCount != 2 || !st.Promoted {
+			t.Errorf("%d. %s: decoded %+v", i, s, st)
+		}
+	}
+}
+
+func TestDecodeLenientDrift(t *testing.T) {
+	d := NewDriftRecorder()
+	s := `[
+		{"trackId": "1", "streamable": "", "trackNumber": "first", "newField": 1, "genres": [{"genreId": 2, "genreName": 5, "colour": "blue"}]},
+		{"trackId": "2", "newField": 2}
+	]`
+
+	var ts []Track
+	if err := decodeLenient("tracksInfo", strings.NewReader(s), &ts, d); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 || ts[0].Id != 1 || ts[0].Genres[0].Id != 2 || ts[0].Genres[0].Name != "5" {
+		t.Errorf("unexpected tracks %+v", ts)
+	}
+
+	r := d.Report()["tracksInfo"]
+	for k, want := range map[string]int{
+		"[].newField":        2,
+		"[].genres[].colour": 1,
+	} {
+		if r.UnknownFields[k] != want {
+			t.Errorf("expected unknown field %s %d times got %d", k, want, r.UnknownFields[k])
+		}
+	}
+	for _, k := range []string{
+		"[].streamable: got empty string want bool",
+		"[].trackNumber: got string want int",
+		"[].genres[].genreName: got number want string",
+	} {
+		if r.TypeMismatches[k] != 1 {
+			t.Errorf("expected mismatch %q got %v", k, r.TypeMismatches)
+		}
+	}
+	if !strings.Contains(d.String(), "tracksInfo: unknown field [].newField (2)") {
+		t.Errorf("unexpected report:\n%s", d)
+	}
+
+	d.Reset()
+	if len(d.Report()) != 0 {
+		t.Errorf("expected an empty report after Reset")
+	}
+}
+
+func TestClientDrift(t *testing.T) {
+	d := NewDriftRecorder()
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"artistsInfo": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"artistId": 7, "artistName": "Nina Simone", "isVarious": false, "born": "1933"}]`))
+		},
+	})
+	c.Option(Drift(d))
+
+	as, err := c.ArtistsInfo(7)
+	if err != nil || len(as) != 1 || as[0].Name != "Nina Simone" {
+		t.Fatalf("unexpected result %+v, %v", as, err)
+	}
+	if d.Report()["artistsInfo"].UnknownFields["[].born"] != 1 {
+		t.Errorf("expected the unknown field to be recorded:\n%s", d)
+	}
+}
+
+func TestDecodeLenientForm(t *testing.T) {
+	d := NewDriftRecorder()
+	s := `{"trackId": 1, "streamable": true, "duration": 60}`
+
+	var tr Track
+	if err := decodeLenient("tracksInfo", strings.NewReader(s), &tr, d); err != nil {
+		t.Fatal(err)
+	}
+
+	r := d.Report()["tracksInfo"]
+	for _, k := range []string{
+		"trackId: got number want string",
+		"streamable: got boolean want string",
+	} {
+		if r.TypeMismatches[k] != 1 {
+			t.Errorf("expected mismatch %q got %v", k, r.TypeMismatches)
+		}
+	}
+	if len(r.TypeMismatches) != 2 || len(r.UnknownFields) != 0 {
+		t.Errorf("unexpected drift:\n%s", d)
+	}
+}
@@ -0,0 +1,224 @@
+package mediagraft
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLoaderWindow is how long a Loader waits to gather lookups
+	// into a batch
+	DefaultLoaderWindow = 5 * time.Millisecond
+)
+
+// Loader coalesces concurrent single entity lookups into batched info
+// calls. Lookups made within the loader's window of the first lookup
+// in a batch are sent together, identical ids are only requested once,
+// and each caller receives just the entity it asked for.
+//
+// Stations have no batched endpoint, so concurrent lookups of the same
+// station share a single GetStation call instead.
+//
+// Callers that asked for the same id in a batch, or the same station,
+// are all given the same pointer. The returned values must be treated
+// as read only, copy them before making any changes.
+type Loader struct {
+	client   *Client
+	window   time.Duration
+	maxBatch int
+
+	tracks  *batcher[Track]
+	albums  *batcher[Album]
+	artists *batcher[Artist]
+
+	stationsLock sync.Mutex
+	stations     map[StationIdent]*stationCall
+}
+
+type loaderOpt func(l *Loader) loaderOpt
+
+// NewLoader returns a Loader for the client with the options applied
+func NewLoader(c *Client, opts ...loaderOpt) *Loader {
+	l := &Loader{
+		client:   c,
+		window:   DefaultLoaderWindow,
+		maxBatch: c.LookupChunkSize(),
+		stations: make(map[StationIdent]*stationCall),
+	}
+	l.Option(opts...)
+
+	l.tracks = &batcher[Track]{loader: l, lookup: tracksLookup}
+	l.albums = &batcher[Album]{loader: l, lookup: albumsLookup}
+	l.artists = &batcher[Artist]{loader: l, lookup: artistsLookup}
+
+	return l
+}
+
+// Option sets the options specified.
+// It returns an option to restore the last arg's previous value.
+func (l *Loader) Option(opts ...loaderOpt) (previous loaderOpt) {
+	for _, opt := range opts {
+		previous = opt(l)
+	}
+	return previous
+}
+
+// LoaderWindow sets how long the loader waits to gather lookups into
+// a batch
+func LoaderWindow(d time.Duration) loaderOpt {
+	return func(l *Loader) loaderOpt {
+		previous := l.window
+		l.window = d
+		return LoaderWindow(previous)
+	}
+}
+
+func (l *Loader) Window() time.Duration {
+	return l.window
+}
+
+// LoaderMaxBatch sets the most ids gathered into a batch, a batch is
+// sent as soon as it is full without waiting for the window to end
+func LoaderMaxBatch(n int) loaderOpt {
+	return func(l *Loader) loaderOpt {
+		previous := l.maxBatch
+		l.maxBatch = n
+		return LoaderMaxBatch(previous)
+	}
+}
+
+func (l *Loader) MaxBatch() int {
+	return l.maxBatch
+}
+
+// Track returns the track with the given id, it is shared with any other
+// callers of the same id and must not be modified
+func (l *Loader) Track(id TrackID) (*Track, error) {
+	return l.tracks.load(int(id))
+}
+
+// Album returns the album with the given id, it is shared with any other
+// callers of the same id and must not be modified
+func (l *Loader) Album(id AlbumID) (*Album, error) {
+	return l.albums.load(int(id))
+}
+
+// Artist returns the artist with the given id, it is shared with any other
+// callers of the same id and must not be modified
+func (l *Loader) Artist(id ArtistID) (*Artist, error) {
+	return l.artists.load(int(id))
+}
+
+type stationCall struct {
+	done    chan struct{}
+	station *Station
+	err     error
+}
+
+// Station returns the station with the given ident, it is shared with
+// any concurrent callers of the same ident and must not be modified
+func (l *Loader) Station(ident StationIdent) (*Station, error) {
+	l.stationsLock.Lock()
+	call, ok := l.stations[ident]
+	if !ok {
+		call = &stationCall{done: make(chan struct{})}
+		l.stations[ident] = call
+	}
+	l.stationsLock.Unlock()
+
+	if !ok {
+		call.station, call.err = l.client.GetStation(ident)
+
+		l.stationsLock.Lock()
+		delete(l.stations, ident)
+		l.stationsLock.Unlock()
+		close(call.done)
+	}
+
+	<-call.done
+	return call.station, call.err
+}
+
+// batcher gathers lookups of one entity type into batches
+type batcher[T any] struct {
+	loader *Loader
+	lookup entityLookup[T]
+
+	lock    sync.Mutex
+	pending *batch[T]
+}
+
+type batch[T any] struct {
+	once    sync.Once
+	timer   *time.Timer // Dispatches the batch at the end of the window
+	ids     []int
+	index   map[int]bool
+	done    chan struct{}
+	results map[int]*T
+	err     error
+}
+
+func (b *batcher[T]) load(id int) (*T, error) {
+	b.lock.Lock()
+	bt := b.pending
+	if bt == nil {
+		bt = &batch[T]{
+			index: make(map[int]bool),
+			done:  make(chan struct{}),
+		}
+		b.pending = bt
+		bt.timer = time.AfterFunc(b.loader.window, func() { b.dispatch(bt) })
+	}
+	if !bt.index[id] {
+		bt.index[id] = true
+		bt.ids = append(bt.ids, id)
+	}
+	full := b.loader.maxBatch > 0 && len(bt.ids) >= b.loader.maxBatch
+	if full {
+		// Later lookups must start a new batch, and this one needn't
+		// wait for the window
+		b.pending = nil
+		bt.timer.Stop()
+	}
+	b.lock.Unlock()
+
+	if full {
+		go b.dispatch(bt)
+	}
+
+	<-bt.done
+	if v, ok := bt.results[id]; ok {
+		return v, nil
+	}
+	if bt.err != nil {
+		return nil, bt.err
+	}
+	return nil, &PartialResultError{Method: b.lookup.method, Missing: []int{id}}
+}
+
+// dispatch sends the batch, it is safe to call more than once
+func (b *batcher[T]) dispatch(bt *batch[T]) {
+	bt.once.Do(func() {
+		b.lock.Lock()
+		if b.pending == bt {
+			b.pending = nil
+		}
+		b.lock.Unlock()
+
+		vs, err := lookup(b.loader.client, b.lookup, bt.ids)
+
+		var perr *PartialResultError
+		if errors.As(err, &perr) {
+			// Missing ids are reported to their callers individually
+			err = nil
+		}
+
+		bt.results = make(map[int]*T, len(vs))
+		for i := range vs {
+			bt.results[b.lookup.id(&vs[i])] = &vs[i]
+		}
+		bt.err = err
+		close(bt.done)
+	})
+}
@@ -0,0 +1,107 @@
+package mediagraft
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoaderCoalesces(t *testing.T) {
+	stats := &lookupStats{}
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracksInfoHandler(t, "ids", map[int]bool{7: true}, DefaultLookupChunkSize, stats),
+	})
+	l := NewLoader(c, LoaderWindow(50*time.Millisecond))
+
+	ids := []TrackID{1, 2, 3, 2, 1, 7, 4}
+	tracks := make([]*Track, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id TrackID) {
+			defer wg.Done()
+			tracks[i], errs[i] = l.Track(id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	if stats.calls != 1 {
+		t.Errorf("expected 1 batched call got %d", stats.calls)
+	}
+
+	for i, id := range ids {
+		if id == 7 {
+			var perr *PartialResultError
+			if !errors.As(errs[i], &perr) || perr.Missing[0] != 7 {
+				t.Errorf("%d. expected a *PartialResultError for 7 got %v", i, errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("%d. unexpected error %v", i, errs[i])
+			continue
+		}
+		if tracks[i].Id != id {
+			t.Errorf("%d. expected track %d got %d", i, id, tracks[i].Id)
+		}
+	}
+}
+
+func TestLoaderMaxBatch(t *testing.T) {
+	stats := &lookupStats{}
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracksInfoHandler(t, "ids", nil, 2, stats),
+	})
+	// A long window proves full batches don't wait for it
+	l := NewLoader(c, LoaderWindow(time.Hour), LoaderMaxBatch(2))
+
+	var wg sync.WaitGroup
+	for _, id := range []TrackID{1, 2, 3, 4} {
+		wg.Add(1)
+		go func(id TrackID) {
+			defer wg.Done()
+			if _, err := l.Track(id); err != nil {
+				t.Error(err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if stats.calls != 2 {
+		t.Errorf("expected 2 batched calls got %d", stats.calls)
+	}
+}
+
+func TestLoaderStation(t *testing.T) {
+	stats := &lookupStats{}
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"radio/getStation": func(w http.ResponseWriter, r *http.Request) {
+			stats.begin()
+			defer stats.end()
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte(`{"id":"` + r.URL.Query().Get("stationIdent") + `","name":"test"}`))
+		},
+	})
+	l := NewLoader(c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := l.Station("a42")
+			if err != nil || s.ID != "a42" {
+				t.Errorf("unexpected station %+v, %v", s, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats.calls != 1 {
+		t.Errorf("expected 1 shared call got %d", stats.calls)
+	}
+}
@@ -0,0 +1,282 @@
+package mediagraft
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultLookupChunkSize is the most ids sent in a single info
+	// call unless LookupChunkSize is set
+	DefaultLookupChunkSize = 50
+	// DefaultLookupConcurrency is the most info calls made at once
+	// for a single lookup
+	DefaultLookupConcurrency = 4
+)
+
+// PartialResultError is returned by the info calls when some of the
+// requested ids were not returned by the server. The results for the
+// ids that were found are still returned.
+type PartialResultError struct {
+	Method  string
+	Missing []int
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("%s: %d ids not found: %v", e.Method, len(e.Missing), e.Missing)
+}
+
+// LookupChunkSize sets the most ids sent in a single info call
+func LookupChunkSize(n int) option {
+	return func(c *Client) option {
+		previous := c.lookupChunkSize
+		c.lookupChunkSize = n
+		return LookupChunkSize(previous)
+	}
+}
+
+func (c *Client) LookupChunkSize() int {
+	if c.lookupChunkSize <= 0 {
+		return DefaultLookupChunkSize
+	}
+	return c.lookupChunkSize
+}
+
+// LookupConcurrency sets the most info calls made at once for a
+// single lookup
+func LookupConcurrency(n int) option {
+	return func(c *Client) option {
+		previous := c.lookupConcurrency
+		c.lookupConcurrency = n
+		return LookupConcurrency(previous)
+	}
+}
+
+func (c *Client) LookupConcurrency() int {
+	if c.lookupConcurrency <= 0 {
+		return DefaultLookupConcurrency
+	}
+	return c.lookupConcurrency
+}
+
+// entityLookup describes an info call that fetches entities by id
+type entityLookup[T any] struct {
+	method  string       // The API method e.g. tracksInfo
+	idParam string       // The query parameter the ids are passed in
+	id      func(*T) int // Returns the id the entity was requested by
+}
+
+var (
+	tracksLookup = entityLookup[Track]{
+		method:  "tracksInfo",
+		idParam: "ids",
+		id:      func(t *Track) int { return int(t.Id) },
+	}
+	trackVersionsLookup = entityLookup[Track]{
+		method:  "tracksInfo",
+		idParam: "versionIds",
+		id:      func(t *Track) int { return int(t.TrackVersionId) },
+	}
+	albumsLookup = entityLookup[Album]{
+		method:  "albumsInfo",
+		idParam: "ids",
+		id:      func(a *Album) int { return int(a.Id) },
+	}
+	artistsLookup = entityLookup[Artist]{
+		method:  "artistsInfo",
+		idParam: "ids",
+		id:      func(a *Artist) int { return int(a.Id) },
+	}
+)
+
+// lookup fetches the entities for ids, splitting them into chunks of
+// at most LookupChunkSize ids which are fetched concurrently. The
+// results are returned in the order of ids, if any ids are missing
+// from the results a *PartialResultError is returned with them. Once a
+// chunk fails no more are sent and its error is returned.
+func lookup[T any](c *Client, l entityLookup[T], ids []int) ([]T, error) {
+	chunks := chunkIDs(ids, c.LookupChunkSize())
+
+	found := make(map[int]*T, len(ids))
+	var (
+		lock     sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, c.LookupConcurrency())
+	)
+
+	for _, chunk := range chunks {
+		sem <- struct{}{}
+		lock.Lock()
+		failed := firstErr != nil
+		lock.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(chunk []int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			vs, err := l.fetch(c, chunk)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for i := range vs {
+				found[l.id(&vs[i])] = &vs[i]
+			}
+		}(chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	res := make([]T, 0, len(ids))
+	var missing []int
+	for _, id := range ids {
+		v, ok := found[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		res = append(res, *v)
+	}
+
+	if len(missing) != 0 {
+		return res, &PartialResultError{Method: l.method, Missing: missing}
+	}
+	return res, nil
+}
+
+// fetch makes a single info call for the ids
+func (l entityLookup[T]) fetch(c *Client, ids []int) ([]T, error) {
+	r, err := c.Call("GET", l.method, l.args(ids), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", l.method, r.Status)
+	}
+
+	var vs []T
+	err = c.decode(l.method, r.Body, &vs)
+	if err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+func (l entityLookup[T]) args(ids []int) *url.Values {
+	args := &url.Values{}
+
+	var strids []string
+	for _, t := range ids {
+		strids = append(strids, strconv.Itoa(t))
+	}
+	args.Set(l.idParam, strings.Join(strids, ","))
+	args.Set("detail", "full")
+
+	return args
+}
+
+// raw makes a single info call for all the ids
+func (l entityLookup[T]) raw(c *Client, ids []int) (*RawResponse, error) {
+	return c.CallRaw("GET", l.method, l.args(ids), nil)
+}
+
+// iter returns an iterator over the entities for ids, fetched a chunk
+// at a time. The entities are returned in the order the server sends
+// them, if any ids are missing Err returns a *PartialResultError with
+// them once the iteration is complete.
+func (l entityLookup[T]) iter(c *Client, ids []int) *Iterator[T] {
+	chunks := chunkIDs(ids, c.LookupChunkSize())
+	found := make(map[int]bool, len(ids))
+
+	return &Iterator[T]{
+		c:      c,
+		method: l.method,
+		open: func() (io.ReadCloser, bool, error) {
+			if len(chunks) == 0 {
+				return nil, false, nil
+			}
+			chunk := chunks[0]
+			chunks = chunks[1:]
+
+			r, err := c.Call("GET", l.method, l.args(chunk), nil)
+			if err != nil {
+				return nil, false, err
+			}
+			if r.StatusCode < 200 || r.StatusCode > 299 {
+				r.Body.Close()
+				return nil, false, fmt.Errorf("%s: %s", l.method, r.Status)
+			}
+			return r.Body, true, nil
+		},
+		seen: func(v *T) {
+			found[l.id(v)] = true
+		},
+		finish: func() error {
+			var missing []int
+			for _, id := range ids {
+				if !found[id] {
+					missing = append(missing, id)
+				}
+			}
+			if len(missing) != 0 {
+				return &PartialResultError{Method: l.method, Missing: missing}
+			}
+			return nil
+		},
+	}
+}
+
+// chunkIDs splits ids into chunks of at most n unique ids
+func chunkIDs(ids []int, n int) [][]int {
+	seen := make(map[int]bool, len(ids))
+
+	var chunks [][]int
+	var chunk []int
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		chunk = append(chunk, id)
+		if len(chunk) == n {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+	}
+	if len(chunk) != 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+// intIDs converts typed ids to the ids sent in an info call
+func intIDs[T ~int](ids []T) []int {
+	res := make([]int, len(ids))
+	for i, id := range ids {
+		res[i] = int(id)
+	}
+	return res
+}
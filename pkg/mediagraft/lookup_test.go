@@ -0,0 +1,188 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChunkIDs(t *testing.T) {
+	got := chunkIDs([]int{1, 2, 3, 2, 4, 5, 1, 6, 7}, 3)
+	want := [][]int{{1, 2, 3}, {4, 5, 6}, {7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+	if got := chunkIDs(nil, 3); len(got) != 0 {
+		t.Errorf("expected no chunks got %v", got)
+	}
+}
+
+// tracksInfoHandler serves tracks for the requested ids in reverse
+// order, except those in missing
+func tracksInfoHandler(t *testing.T, param string, missing map[int]bool, maxIDs int, stats *lookupStats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats.begin()
+		defer stats.end()
+
+		ids := strings.Split(r.URL.Query().Get(param), ",")
+		if len(ids) > maxIDs {
+			t.Errorf("got %d ids, more than the maximum %d", len(ids), maxIDs)
+		}
+
+		var ts []map[string]string
+		for i := len(ids) - 1; i >= 0; i-- {
+			id, _ := strconv.Atoi(ids[i])
+			if missing[id] {
+				continue
+			}
+			tr := map[string]string{"trackTitle": "track " + ids[i]}
+			if param == "versionIds" {
+				tr["trackId"] = strconv.Itoa(id + 1000)
+				tr["trackVersionId"] = ids[i]
+			} else {
+				tr["trackId"] = ids[i]
+			}
+			ts = append(ts, tr)
+		}
+		json.NewEncoder(w).Encode(ts)
+	}
+}
+
+type lookupStats struct {
+	lock          sync.Mutex
+	calls         int
+	inFlight      int
+	maxConcurrent int
+}
+
+func (s *lookupStats) begin() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls++
+	s.inFlight++
+	if s.inFlight > s.maxConcurrent {
+		s.maxConcurrent = s.inFlight
+	}
+}
+
+func (s *lookupStats) end() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.inFlight--
+}
+
+func TestTracksInfoChunked(t *testing.T) {
+	stats := &lookupStats{}
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracksInfoHandler(t, "ids", nil, 10, stats),
+	})
+	c.Option(LookupChunkSize(10), LookupConcurrency(3))
+
+	var ids []TrackID
+	for i := TrackID(95); i > 0; i-- {
+		ids = append(ids, i)
+	}
+
+	ts, err := c.TracksInfo(ids...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != len(ids) {
+		t.Fatalf("expected %d tracks got %d", len(ids), len(ts))
+	}
+	for i, tr := range ts {
+		if tr.Id != ids[i] {
+			t.Fatalf("%d. expected track %d got %d, results are not in request order", i, ids[i], tr.Id)
+		}
+	}
+
+	if stats.calls != 10 {
+		t.Errorf("expected 10 calls got %d", stats.calls)
+	}
+	if stats.maxConcurrent > 3 {
+		t.Errorf("expected at most 3 concurrent calls got %d", stats.maxConcurrent)
+	}
+}
+
+func TestTrackVersionsInfo(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracksInfoHandler(t, "versionIds", nil, DefaultLookupChunkSize, &lookupStats{}),
+	})
+
+	ts, err := c.TrackVersionsInfo(3, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []TrackVersionID{3, 1, 2} {
+		if ts[i].TrackVersionId != want {
+			t.Errorf("%d. expected version %d got %d", i, want, ts[i].TrackVersionId)
+		}
+	}
+}
+
+func TestTracksInfoMissing(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": tracksInfoHandler(t, "ids", map[int]bool{2: true, 5: true}, 2, &lookupStats{}),
+	})
+	c.Option(LookupChunkSize(2))
+
+	ts, err := c.TracksInfo(1, 2, 3, 4, 5, 3)
+	var perr *PartialResultError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a *PartialResultError got %v", err)
+	}
+	if !reflect.DeepEqual(perr.Missing, []int{2, 5}) {
+		t.Errorf("expected missing [2 5] got %v", perr.Missing)
+	}
+
+	var got []TrackID
+	for _, tr := range ts {
+		got = append(got, tr.Id)
+	}
+	if !reflect.DeepEqual(got, []TrackID{1, 3, 4, 3}) {
+		t.Errorf("expected tracks [1 3 4 3] got %v", got)
+	}
+}
+
+func TestTracksInfoError(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("not json"))
+		},
+	})
+
+	if ts, err := c.TracksInfo(1); err == nil || ts != nil {
+		t.Errorf("expected an error and no tracks got %v, %v", ts, err)
+	}
+}
+
+func TestTracksInfoStatus(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		},
+	})
+	c.Option(LookupChunkSize(1), LookupConcurrency(1))
+
+	var perr *PartialResultError
+	ts, err := c.TracksInfo(1, 2, 3, 4)
+	if err == nil || errors.As(err, &perr) || !strings.Contains(err.Error(), "503") || ts != nil {
+		t.Errorf("expected a 503 error and no tracks got %v, %v", ts, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no more chunks to be sent after one failed got %d calls", calls)
+	}
+
+	it := c.TracksInfoIter(1)
+	if it.Next() || it.Err() == nil || !strings.Contains(it.Err().Error(), "503") {
+		t.Errorf("expected the iterator to fail with a 503 got %v", it.Err())
+	}
+}
@@ -0,0 +1,374 @@
+package mediagraft
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultMatchConcurrency is the most match requests a Matcher
+	// makes at once
+	DefaultMatchConcurrency = 4
+	// DefaultMatchInterval is the least time between a Matcher's
+	// calls, limiting it to 10 calls a second
+	DefaultMatchInterval = 100 * time.Millisecond
+	// DefaultMatchThreshold is the least confidence a match must have
+	// to be accepted
+	DefaultMatchThreshold = 0.6
+
+	// DefaultMatchFallbackResults is the number of simpleSearch
+	// results considered when findMatch doesn't find a good match
+	DefaultMatchFallbackResults = 10
+)
+
+// MatchRequest is a track from another service to find
+type MatchRequest struct {
+	Title    string
+	Artist   string
+	Album    string        // Not used for matching, kept for reports
+	Duration time.Duration // Zero if unknown
+}
+
+// Match is the result of matching a request
+type Match struct {
+	Request    MatchRequest
+	Track      *Track  // The best match, nil if none was good enough
+	Confidence float64 // From 0 to 1, the best candidate's even if it wasn't good enough
+	Method     string  // The API method that found the track
+	Err        error
+}
+
+// Matched returns true if a track was matched
+func (m *Match) Matched() bool {
+	return m.Track != nil
+}
+
+// MatchReport holds the matches for a set of requests, in the order of
+// the requests
+type MatchReport struct {
+	Matches []Match
+}
+
+// Matched returns the matches that found a track
+func (r *MatchReport) Matched() []Match {
+	return r.filter(true)
+}
+
+// Unmatched returns the matches that didn't find a track, including
+// those that failed
+func (r *MatchReport) Unmatched() []Match {
+	return r.filter(false)
+}
+
+func (r *MatchReport) filter(matched bool) []Match {
+	var ms []Match
+	for _, m := range r.Matches {
+		if m.Matched() == matched {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// WriteCSV writes the report as CSV with a header row
+func (r *MatchReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"title", "artist", "duration", "matched", "trackId", "trackTitle", "trackArtist", "confidence", "method", "error"})
+	for _, m := range r.Matches {
+		row := []string{
+			m.Request.Title,
+			m.Request.Artist,
+			"",
+			strconv.FormatBool(m.Matched()),
+			"", "", "",
+			strconv.FormatFloat(m.Confidence, 'f', 2, 64),
+			m.Method,
+			"",
+		}
+		if m.Request.Duration != 0 {
+			row[2] = formatSeconds(m.Request.Duration)
+		}
+		if m.Track != nil {
+			row[4] = strconv.Itoa(int(m.Track.Id))
+			row[5] = m.Track.Title
+			row[6] = m.Track.ArtistName()
+		}
+		if m.Err != nil {
+			row[9] = m.Err.Error()
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Matcher matches tracks from other services to Mediagraft tracks in
+// bulk. Each request is looked up with findMatch, falling back to a
+// simpleSearch if that doesn't find a good enough match, and the
+// candidates are scored on their title, artist and duration.
+type Matcher struct {
+	client      *Client
+	concurrency int
+	interval    time.Duration
+	threshold   float64
+	fallback    bool
+
+	rateLock sync.Mutex
+	nextCall time.Time
+}
+
+type matcherOpt func(m *Matcher) matcherOpt
+
+// NewMatcher returns a Matcher for the client with the options applied
+func NewMatcher(c *Client, opts ...matcherOpt) *Matcher {
+	m := &Matcher{
+		client:      c,
+		concurrency: DefaultMatchConcurrency,
+		interval:    DefaultMatchInterval,
+		threshold:   DefaultMatchThreshold,
+		fallback:    true,
+	}
+	m.Option(opts...)
+	return m
+}
+
+// Option sets the options specified.
+// It returns an option to restore the last arg's previous value.
+func (m *Matcher) Option(opts ...matcherOpt) (previous matcherOpt) {
+	for _, opt := range opts {
+		previous = opt(m)
+	}
+	return previous
+}
+
+// MatchConcurrency sets the most requests matched at once
+func MatchConcurrency(n int) matcherOpt {
+	return func(m *Matcher) matcherOpt {
+		previous := m.concurrency
+		m.concurrency = n
+		return MatchConcurrency(previous)
+	}
+}
+
+// MatchInterval sets the least time between calls, zero disables the
+// rate limit
+func MatchInterval(d time.Duration) matcherOpt {
+	return func(m *Matcher) matcherOpt {
+		previous := m.interval
+		m.interval = d
+		return MatchInterval(previous)
+	}
+}
+
+// MatchThreshold sets the least confidence a match must have
+func MatchThreshold(t float64) matcherOpt {
+	return func(m *Matcher) matcherOpt {
+		previous := m.threshold
+		m.threshold = t
+		return MatchThreshold(previous)
+	}
+}
+
+// MatchFallback sets whether a simpleSearch is tried when findMatch
+// doesn't find a good enough match
+func MatchFallback(b bool) matcherOpt {
+	return func(m *Matcher) matcherOpt {
+		previous := m.fallback
+		m.fallback = b
+		return MatchFallback(previous)
+	}
+}
+
+// Match matches the requests concurrently, the report has a match for
+// every request in the same order
+func (m *Matcher) Match(reqs []MatchRequest) *MatchReport {
+	r := &MatchReport{Matches: make([]Match, len(reqs))}
+
+	concurrency := m.concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, req MatchRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.Matches[i] = m.MatchOne(req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	return r
+}
+
+// MatchOne matches a single request
+func (m *Matcher) MatchOne(req MatchRequest) Match {
+	match := Match{Request: req}
+
+	m.wait()
+	sr, err := m.client.FindMatch(req.Title, req.Artist, []SearchType{SearchTracks})
+	if err != nil {
+		match.Err = err
+		return match
+	}
+	match.best(req, sr.Tracks, "findMatch")
+
+	if match.Confidence < m.threshold && m.fallback {
+		m.wait()
+		sr, err := m.client.SimpleSearch(req.Title+" "+req.Artist, []SearchType{SearchTracks},
+			LimitBegin(0), LimitEnd(DefaultMatchFallbackResults))
+		if err != nil {
+			match.Err = err
+		} else {
+			match.best(req, sr.Tracks, "simpleSearch")
+		}
+	}
+
+	if match.Confidence < m.threshold {
+		match.Track = nil
+	}
+	return match
+}
+
+// best keeps the best scoring of the candidates if it's better than
+// the current match
+func (match *Match) best(req MatchRequest, candidates []Track, method string) {
+	for i := range candidates {
+		if s := ScoreMatch(req, &candidates[i]); s > match.Confidence {
+			match.Track = &candidates[i]
+			match.Confidence = s
+			match.Method = method
+		}
+	}
+}
+
+// wait blocks until the rate limit allows another call
+func (m *Matcher) wait() {
+	if m.interval <= 0 {
+		return
+	}
+
+	m.rateLock.Lock()
+	now := time.Now()
+	at := m.nextCall
+	if at.Before(now) {
+		at = now
+	}
+	m.nextCall = at.Add(m.interval)
+	m.rateLock.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+// ScoreMatch returns the confidence, from 0 to 1, that t is the track
+// requested. Titles and artists are compared after normalizing them,
+// the server's IsAlike flags count as close matches, and durations
+// are compared when the request has one.
+func ScoreMatch(req MatchRequest, t *Track) float64 {
+	title := similarity(req.Title, t.Title)
+	if t.IsAlikeTitleMatch && title < 0.9 {
+		title = 0.9
+	}
+	artist := similarity(req.Artist, t.ArtistName())
+	if t.IsAlikeArtistMatch && artist < 0.9 {
+		artist = 0.9
+	}
+
+	if req.Duration == 0 || t.Duration == 0 {
+		return title*0.6 + artist*0.4
+	}
+	return title*0.5 + artist*0.35 + durationSimilarity(req.Duration, t.Duration)*0.15
+}
+
+// similarity compares normalized strings: 1 if they're equal, 0.8 if
+// one contains the other, otherwise the proportion of shared words
+func similarity(a, b string) float64 {
+	a, b = normalizeMatch(a), normalizeMatch(b)
+	switch {
+	case a == "" || b == "":
+		return 0
+	case a == b:
+		return 1
+	case strings.Contains(a, b) || strings.Contains(b, a):
+		return 0.8
+	}
+
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(a) {
+		words[w] = true
+	}
+	shared, total := 0, len(words)
+	for _, w := range strings.Fields(b) {
+		if words[w] {
+			shared++
+			delete(words, w)
+		} else {
+			total++
+		}
+	}
+	return float64(shared) / float64(total)
+}
+
+// durationSimilarity is 1 for durations within 2 seconds, falling to 0
+// at 30 seconds apart
+func durationSimilarity(a, b time.Duration) float64 {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d <= 2*time.Second:
+		return 1
+	case d >= 30*time.Second:
+		return 0
+	}
+	return 1 - float64(d-2*time.Second)/float64(28*time.Second)
+}
+
+// normalizeMatch lower cases s, drops bracketed qualifiers such as
+// "(Remastered 2010)" and anything after " - " or "feat.", and reduces
+// punctuation and spacing to single spaces
+func normalizeMatch(s string) string {
+	s = strings.ToLower(s)
+	for _, sep := range []string{" - ", " feat. ", " feat ", " ft. ", " featuring "} {
+		if i := strings.Index(s, sep); i > 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.ReplaceAll(s, "&", " and ")
+
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'':
+			// Don't split contractions
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(b.String())
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,130 @@
+package mediagraft
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNormalizeMatch(t *testing.T) {
+	for i, tc := range []struct{ in, want string }{
+		{"Purple Haze", "purple haze"},
+		{"Purple Haze (Remastered 2010)", "purple haze"},
+		{"Purple Haze - Live at Woodstock", "purple haze"},
+		{"All Along the Watchtower [Mono]", "all along the watchtower"},
+		{"Don't Stop Me Now", "dont stop me now"},
+		{"The Jimi Hendrix Experience", "jimi hendrix experience"},
+		{"Simon & Garfunkel", "simon and garfunkel"},
+		{"Hey Joe feat. Someone", "hey joe"},
+		{"  Foxy   Lady!! ", "foxy lady"},
+	} {
+		if got := normalizeMatch(tc.in); got != tc.want {
+			t.Errorf("%d. %q normalized to %q expected %q", i, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestScoreMatch(t *testing.T) {
+	req := MatchRequest{Title: "Purple Haze (Remastered)", Artist: "Jimi Hendrix", Duration: 171 * time.Second}
+	exact := &Track{Title: "Purple Haze", Artist: &ArtistRef{Name: "The Jimi Hendrix Experience"}, Duration: 170 * time.Second}
+	cover := &Track{Title: "Purple Haze", Artist: &ArtistRef{Name: "Dread Zeppelin"}, Duration: 240 * time.Second}
+	alike := &Track{Title: "Purple Haze", Artist: &ArtistRef{Name: "J. Hendrix"}, IsAlikeArtistMatch: true, Duration: 171 * time.Second}
+	other := &Track{Title: "Hey Joe", Artist: &ArtistRef{Name: "Jimi Hendrix"}}
+
+	e, c, a, o := ScoreMatch(req, exact), ScoreMatch(req, cover), ScoreMatch(req, alike), ScoreMatch(req, other)
+	if e < 0.9 || a < 0.9 {
+		t.Errorf("expected close matches to score highly got %v %v", e, a)
+	}
+	if c >= DefaultMatchThreshold || o >= DefaultMatchThreshold {
+		t.Errorf("expected poor matches to score below the threshold got %v %v", c, o)
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	var (
+		lock                 sync.Mutex
+		findCalls, fallbacks int
+	)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"findMatch": func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			findCalls++
+			lock.Unlock()
+			switch r.URL.Query().Get("title") {
+			case "Purple Haze":
+				w.Write([]byte(`{"tracks": [
+					{"trackId": "2", "trackTitle": "Purple Haze", "artistName": "Dread Zeppelin"},
+					{"trackId": "1", "trackTitle": "Purple Haze", "artistName": "Jimi Hendrix"}
+				]}`))
+			default:
+				w.Write([]byte(`{"tracks": []}`))
+			}
+		},
+		"simpleSearch": func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			fallbacks++
+			lock.Unlock()
+			switch r.URL.Query().Get("query") {
+			case "Hey Joe Jimi Hendrix":
+				w.Write([]byte(`{"tracks": [{"trackId": "3", "trackTitle": "Hey Joe", "artistName": "Jimi Hendrix", "isAlikeTitleMatch": "true"}]}`))
+			default:
+				w.Write([]byte(`{"tracks": [{"trackId": "4", "trackTitle": "Something Else", "artistName": "Someone"}]}`))
+			}
+		},
+	})
+
+	reqs := []MatchRequest{
+		{Title: "Purple Haze", Artist: "Jimi Hendrix"},
+		{Title: "Hey Joe", Artist: "Jimi Hendrix"},
+		{Title: "Unknown Song", Artist: "Nobody"},
+	}
+	start := time.Now()
+	r := NewMatcher(c, MatchConcurrency(2), MatchInterval(20*time.Millisecond)).Match(reqs)
+
+	// 3 findMatch calls and 2 fallbacks, at least 20ms apart
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected calls to be rate limited, took %v", elapsed)
+	}
+	if findCalls != 3 || fallbacks != 2 {
+		t.Errorf("expected 3 findMatch and 2 simpleSearch calls got %d and %d", findCalls, fallbacks)
+	}
+
+	for i, want := range []struct {
+		id     TrackID
+		method string
+	}{
+		{1, "findMatch"},
+		{3, "simpleSearch"},
+		{0, ""},
+	} {
+		m := r.Matches[i]
+		if m.Err != nil {
+			t.Errorf("%d. %v", i, m.Err)
+		}
+		if m.Request != reqs[i] || m.Method != want.method {
+			t.Errorf("%d. unexpected match %+v", i, m)
+		}
+		if (want.id == 0) != !m.Matched() || (m.Matched() && m.Track.Id != want.id) {
+			t.Errorf("%d. expected track %d got %+v", i, want.id, m.Track)
+		}
+	}
+
+	if len(r.Matched()) != 2 || len(r.Unmatched()) != 1 || r.Unmatched()[0].Request.Title != "Unknown Song" {
+		t.Errorf("unexpected report %+v", r)
+	}
+
+	var b bytes.Buffer
+	if err := r.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[1][4] != "1" || rows[1][6] != "Jimi Hendrix" || rows[3][3] != "false" || rows[3][4] != "" {
+		t.Errorf("unexpected csv %v", rows)
+	}
+}
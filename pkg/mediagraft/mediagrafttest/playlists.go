@@ -0,0 +1,187 @@
+package mediagrafttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	mg "github.com/we7/go-mediagraft/pkg/mediagraft"
+)
+
+// Playlists is an in memory implementation of the playlist calls for
+// the signed in user, the zero value has no playlists. Changes made
+// against an old playlistVersion fail with 409 Conflict.
+//
+// Only userPlaylistsInfo is a documented API call. The names and
+// parameters of playlistsInfo, createPlaylist, renamePlaylist,
+// deletePlaylist, addTracksToPlaylist, removeTracksFromPlaylist and
+// reorderPlaylistTracks, and the 409 for a stale version, are
+// assumptions matching the client rather than the real server.
+type Playlists struct {
+	lock      sync.Mutex
+	nextId    mg.PlaylistID
+	playlists []*mg.Playlist
+}
+
+// Handlers returns the playlist calls, see Merge to combine them with
+// any others
+func (f *Playlists) Handlers() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"userPlaylistsInfo": f.serve(false, func(q query) (interface{}, int) {
+			ps := []mg.Playlist{}
+			for _, p := range f.playlists {
+				cp := *p
+				if q.Get("detail") != "full" {
+					cp.Tracks = nil
+				}
+				ps = append(ps, cp)
+			}
+			return ps, http.StatusOK
+		}),
+		"playlistsInfo": f.serve(false, func(q query) (interface{}, int) {
+			ps := []mg.Playlist{}
+			for _, id := range q.ints("ids") {
+				if i := f.find(mg.PlaylistID(id)); i >= 0 {
+					ps = append(ps, *f.playlists[i])
+				}
+			}
+			return ps, http.StatusOK
+		}),
+		"createPlaylist": f.serve(false, func(q query) (interface{}, int) {
+			f.nextId++
+			p := &mg.Playlist{
+				Id:          f.nextId,
+				Name:        q.Get("playlistName"),
+				Description: q.Get("playlistDescription"),
+				Version:     1,
+				Tracks:      []mg.Track{},
+				User:        mg.User{UserId: 1, UserName: "test"},
+			}
+			f.playlists = append(f.playlists, p)
+			return p, http.StatusOK
+		}),
+		"renamePlaylist": f.serve(true, func(q query) (interface{}, int) {
+			p := f.playlists[f.find(q.id())]
+			p.Name = q.Get("playlistName")
+			p.Version++
+			return p, http.StatusOK
+		}),
+		"deletePlaylist": f.serve(true, func(q query) (interface{}, int) {
+			i := f.find(q.id())
+			f.playlists = append(f.playlists[:i], f.playlists[i+1:]...)
+			return map[string]string{}, http.StatusOK
+		}),
+		"addTracksToPlaylist": f.serve(true, func(q query) (interface{}, int) {
+			p := f.playlists[f.find(q.id())]
+			var ts []mg.Track
+			for _, id := range q.ints("trackIds") {
+				ts = append(ts, mg.Track{Id: mg.TrackID(id), Title: "track " + strconv.Itoa(id)})
+			}
+			pos := len(p.Tracks)
+			if q.Has("position") {
+				pos = q.int("position")
+			}
+			if pos < 0 || pos > len(p.Tracks) {
+				return nil, http.StatusBadRequest
+			}
+			p.Tracks = append(p.Tracks[:pos:pos], append(ts, p.Tracks[pos:]...)...)
+			p.Version++
+			return p, http.StatusOK
+		}),
+		"removeTracksFromPlaylist": f.serve(true, func(q query) (interface{}, int) {
+			p := f.playlists[f.find(q.id())]
+			remove := map[int]bool{}
+			for _, pos := range q.ints("positions") {
+				if pos < 0 || pos >= len(p.Tracks) {
+					return nil, http.StatusBadRequest
+				}
+				remove[pos] = true
+			}
+			ts := []mg.Track{}
+			for i, t := range p.Tracks {
+				if !remove[i] {
+					ts = append(ts, t)
+				}
+			}
+			p.Tracks = ts
+			p.Version++
+			return p, http.StatusOK
+		}),
+		"reorderPlaylistTracks": f.serve(true, func(q query) (interface{}, int) {
+			p := f.playlists[f.find(q.id())]
+			from, to := q.int("from"), q.int("to")
+			if from < 0 || from >= len(p.Tracks) || to < 0 || to >= len(p.Tracks) {
+				return nil, http.StatusBadRequest
+			}
+			t := p.Tracks[from]
+			p.Tracks = append(p.Tracks[:from], p.Tracks[from+1:]...)
+			p.Tracks = append(p.Tracks[:to], append([]mg.Track{t}, p.Tracks[to:]...)...)
+			p.Version++
+			return p, http.StatusOK
+		}),
+	}
+}
+
+// serve wraps a playlist call, checking the playlist exists and is at
+// the version sent if change is true
+func (f *Playlists) serve(change bool, h func(q query) (interface{}, int)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		q := query{r.URL.Query()}
+		if change {
+			i := f.find(q.id())
+			if i < 0 {
+				http.NotFound(w, r)
+				return
+			}
+			if q.int("playlistVersion") != f.playlists[i].Version {
+				http.Error(w, "playlist version mismatch", http.StatusConflict)
+				return
+			}
+		}
+
+		v, status := h(q)
+		if status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func (f *Playlists) find(id mg.PlaylistID) int {
+	for i, p := range f.playlists {
+		if p.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+type query struct {
+	url.Values
+}
+
+func (q query) id() mg.PlaylistID {
+	return mg.PlaylistID(q.int("playlistId"))
+}
+
+func (q query) int(key string) int {
+	n, _ := strconv.Atoi(q.Get(key))
+	return n
+}
+
+func (q query) ints(key string) []int {
+	var ns []int
+	for _, s := range strings.Split(q.Get(key), ",") {
+		if n, err := strconv.Atoi(s); err == nil {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
@@ -0,0 +1,47 @@
+// Package mediagrafttest provides a fake mediagraft server for testing
+// code that uses the client.
+package mediagrafttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mg "github.com/we7/go-mediagraft/pkg/mediagraft"
+	"github.com/we7/go-mediagraft/pkg/mediagraft/internal/fakeserver"
+)
+
+// ApiVersion is the API version the fake server serves
+const ApiVersion = "0.1"
+
+// NewServer returns a fake server, handlers are keyed by API method
+// e.g. "tracksInfo" or "radio/getStation". Other methods are not
+// found. The server must be closed when done.
+func NewServer(handlers map[string]http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(fakeserver.Handler(ApiVersion, handlers))
+}
+
+// NewClient returns a client talking to a fake server with the
+// handlers, which is closed when the test finishes
+func NewClient(t testing.TB, handlers map[string]http.HandlerFunc) *mg.Client {
+	srv := NewServer(handlers)
+	t.Cleanup(srv.Close)
+
+	c := mg.New(mg.Host(strings.TrimPrefix(srv.URL, "http://")))
+	c.Proto = "http"
+	c.ApiVersion = ApiVersion
+	return c
+}
+
+// Merge returns the handlers of all the sets combined, later sets
+// taking precedence
+func Merge(sets ...map[string]http.HandlerFunc) map[string]http.HandlerFunc {
+	handlers := make(map[string]http.HandlerFunc)
+	for _, s := range sets {
+		for m, h := range s {
+			handlers[m] = h
+		}
+	}
+	return handlers
+}
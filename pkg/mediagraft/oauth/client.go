@@ -1,8 +1,6 @@
 package oauth
 
 import (
-	"crypto/rand"
-	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,7 +10,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
@@ -24,19 +21,55 @@ import (
 type Client struct {
 	verbosity   int
 	httpClient  *http.Client
+	nonces      NonceSource
 	credentials *credentialMap
 }
 
 var DefaultClient = &Client{
 	httpClient:  http.DefaultClient,
+	nonces:      RandomNonces(),
 	verbosity:   0,
 	credentials: &credentialMap{},
 }
 
-// New creates a new instance of an oauth client
-func New() *Client {
-	c := *DefaultClient
-	return &c
+// New creates a new instance of an oauth client with the options
+// applied. Each client has its own credentials and http.Client, so
+// changes to one never affect another.
+func New(opts ...option) *Client {
+	c := &Client{
+		httpClient:  &http.Client{},
+		nonces:      RandomNonces(),
+		credentials: &credentialMap{},
+	}
+	c.Option(opts...)
+	return c
+}
+
+// Clone returns a deep copy of the client. The clone starts with the
+// same credentials and tokens, but they are refreshed independently.
+// While the two sign with the same access token they share the record
+// of the nonces used with it, so neither can reuse a (timestamp,
+// nonce) pair the other has used.
+func (c *Client) Clone() *Client {
+	n := *c
+	if c.httpClient != nil {
+		h := *c.httpClient
+		n.httpClient = &h
+	}
+
+	n.credentials = &credentialMap{}
+	c.credentials.credsLock.RLock()
+	defer c.credentials.credsLock.RUnlock()
+	if c.credentials.creds != nil {
+		n.credentials.creds = make(map[string]*domainScope, len(c.credentials.creds))
+		for k, s := range c.credentials.creds {
+			ns := *s
+			ns.creds = s.creds.clone()
+			n.credentials.creds[k] = &ns
+		}
+	}
+
+	return &n
 }
 
 var (
@@ -47,6 +80,8 @@ var (
 	ErrUnknownClientID    = errors.New("Unknown client ID")
 	ErrBadClientSecret    = errors.New("The client secret supplied does not match the client ID")
 	ErrBadExpiresAt       = errors.New("The expires_at value was unparsable")
+	ErrBadDomain          = errors.New("The domain pattern could not be parsed")
+	ErrNonceReuse         = errors.New("Could not generate a nonce that has not already been used with this token")
 )
 
 type option func(c *Client) option
@@ -86,6 +121,22 @@ func (c *Client) HTTPClient() *http.Client {
 	return c.httpClient
 }
 
+// Nonces sets the NonceSource used when signing requests
+func Nonces(n NonceSource) option {
+	return func(c *Client) option {
+		previous := c.nonces
+		c.nonces = n
+		return Nonces(previous)
+	}
+}
+
+func (c *Client) Nonces() NonceSource {
+	if c.nonces == nil {
+		return RandomNonces()
+	}
+	return c.nonces
+}
+
 // Credentials is the full set of oauth credentials required to
 // log into an oauth service
 type Credentials struct {
@@ -101,8 +152,10 @@ type Credentials struct {
 	Username     string
 	Password     string
 	RedirectURI  string
+	Encoding     QueryEncoding //The query escaping used when signing, must match the server's
 
 	credLock          *sync.RWMutex //http or https, defaults to https
+	nonces            *nonceLedger  // shared by the copies holding the same access token
 	TokenType         string
 	Algorithm         string
 	Secret            string
@@ -112,6 +165,25 @@ type Credentials struct {
 	AuthorizationCode string
 }
 
+// clone returns a copy of the credentials with its own lock, sharing
+// the nonces used with the access token
+func (c *Credentials) clone() *Credentials {
+	c.credLock.Lock()
+	defer c.credLock.Unlock()
+	if c.nonces == nil {
+		c.nonces = &nonceLedger{}
+	}
+	return c.copy()
+}
+
+// copy returns a copy of the credentials with its own lock, without
+// locking the original
+func (c *Credentials) copy() *Credentials {
+	n := *c
+	n.credLock = &sync.RWMutex{}
+	return &n
+}
+
 func DefaultCredentials() Credentials {
 	return Credentials{
 		Proto:        "https",
@@ -126,34 +198,79 @@ func DefaultCredentials() Credentials {
 // CredentialMap maps oauth credentials to the domain they are used within
 type credentialMap struct {
 	credsLock sync.RWMutex
-	creds     map[string]*Credentials
+	creds     map[string]*domainScope
 }
 
-func (c *Client) AddDomain(domain string, creds Credentials) {
+// AddDomain registers creds for use with any request matching domain.
+// The domain is a pattern of the form [scheme://]host[:port], where
+// host may be an exact hostname or a wildcard suffix such as
+// *.stagingf.we7.com. When several patterns match a request the most
+// specific wins: an exact host beats any wildcard, a longer wildcard
+// suffix beats a shorter one, then a pattern with a port beats one
+// without, and finally a pattern with a scheme beats one without.
+// Adding the same pattern twice replaces the earlier credentials.
+func (c *Client) AddDomain(domain string, creds Credentials) error {
+	s, err := parseDomain(domain)
+	if err != nil {
+		return err
+	}
+	s.creds = creds.copy()
+
 	c.credentials.credsLock.Lock()
 	defer c.credentials.credsLock.Unlock()
 	if c.credentials.creds == nil {
-		c.credentials.creds = make(map[string]*Credentials)
+		c.credentials.creds = make(map[string]*domainScope)
 	}
-	c.credentials.creds[domain] = &creds
+	c.credentials.creds[s.String()] = s
+	return nil
 }
 
-func (c *Client) getDomains(domain string) (creds *Credentials, ok bool) {
+// getDomains returns the credentials of the most specific scope
+// matching the given scheme, host and port.
+func (c *Client) getDomains(scheme, host, port string) (creds *Credentials, ok bool) {
 	c.credentials.credsLock.RLock()
 	defer c.credentials.credsLock.RUnlock()
-	creds, ok = c.credentials.creds[domain]
-	return creds, ok
+
+	var best *domainScope
+	for _, s := range c.credentials.creds {
+		if !s.matches(scheme, host, port) {
+			continue
+		}
+		if best == nil || s.moreSpecific(best) {
+			best = s
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.creds, true
 }
 
 // Do is the http.Do implementation that hides oauth
 func (c *Client) Do(r *http.Request) (resp *http.Response, err error) {
-	h, _ := requestedHostPort(r)
-	creds, ok := c.getDomains(h)
+	sig, err := c.Sign(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if sig != nil {
+		r.Header.Set("Authorization", sig.Header)
+	}
+
+	return c.httpClient.Do(r)
+}
+
+// Sign signs the request with the credentials for its domain, fetching
+// or refreshing the token as required, without sending the request.
+// It returns a nil Signature if we have no credentials for the domain.
+func (c *Client) Sign(r *http.Request) (*Signature, error) {
+	h, p := requestedHostPort(r)
+	creds, ok := c.getDomains(r.URL.Scheme, h, p)
 
 	if !ok {
-		// We have no oauth creds for this domain, pass it directly
-		// to the http.Client
-		return c.httpClient.Do(r)
+		// We have no oauth creds for this domain, the request
+		// goes directly to the http.Client
+		return nil, nil
 	}
 
 	// If we have no token, get one: grant_type=passord
@@ -169,14 +286,17 @@ func (c *Client) Do(r *http.Request) (resp *http.Response, err error) {
 	//   if we get another 401 back, assume either our auth is failing, or we
 	//   just aren't allowed to call that endpoint
 
-	err = creds.updateCreds(h, c.httpClient)
+	err := creds.updateCreds(authority(r.URL.Scheme, h, p), c.httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Set("Authorization", creds.Authorization(r, time.Now(), nonce()))
+	t, n, err := creds.claimNonce(time.Now(), c.Nonces())
+	if err != nil {
+		return nil, err
+	}
 
-	return c.httpClient.Do(r)
+	return creds.Sign(r, t, n), nil
 }
 
 // Get is the http.Get implementation that hides oauth
@@ -265,6 +385,9 @@ func (c *Credentials) updateCreds(domain string, cl *http.Client) error {
 	c.TokenType = oresp.TokenType
 	c.Secret = oresp.Secret
 	if oresp.AccessToken != nil {
+		if c.AccessToken != *oresp.AccessToken {
+			c.resetNonces()
+		}
 		c.AccessToken = *oresp.AccessToken
 	}
 	if oresp.RefreshToken != nil {
@@ -347,19 +470,6 @@ func (r *oauthJSONResp) Err() error {
 	}
 }
 
-var nonceCounter uint64
-
-// nonce returns a unique string, stolen from another oauth library
-func nonce() string {
-	n := atomic.AddUint64(&nonceCounter, 1)
-	if n == 1 {
-		binary.Read(rand.Reader, binary.BigEndian, &n)
-		n ^= uint64(time.Now().UnixNano())
-		atomic.CompareAndSwapUint64(&nonceCounter, 1, n)
-	}
-	return strconv.FormatUint(n, 16)
-}
-
 // Return the client and port we should use for the oauth hash
 // this is the host and port as the endpoint would naturally see,
 // vs the target IP and Port the client targets
@@ -0,0 +1,119 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewIsolated(t *testing.T) {
+	a := New()
+	b := New()
+
+	if a.HTTPClient() == b.HTTPClient() || a.HTTPClient() == http.DefaultClient {
+		t.Errorf("expected each client to have its own http.Client")
+	}
+
+	if err := a.AddDomain("api.we7.com", DefaultCredentials()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.getDomains("http", "api.we7.com", "80"); ok {
+		t.Errorf("credentials added to one client leaked into another")
+	}
+	if _, ok := DefaultClient.getDomains("http", "api.we7.com", "80"); ok {
+		t.Errorf("credentials added to a new client leaked into DefaultClient")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	h := &http.Client{Timeout: time.Second}
+	n := SequentialNonces("x")
+	c := New(HTTPClient(h), Verbosity(2), Nonces(n))
+
+	if c.HTTPClient() != h || c.Verbosity() != 2 || c.Nonces() != n {
+		t.Errorf("options were not applied at construction")
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := New(HTTPClient(&http.Client{Timeout: time.Second}))
+	creds := DefaultCredentials()
+	creds.ClientID = "original"
+	creds.AccessToken = "token"
+	if err := c.AddDomain("*.we7.com", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	n := c.Clone()
+	if n.HTTPClient() == c.HTTPClient() || n.HTTPClient().Timeout != time.Second {
+		t.Errorf("expected a copy of the http.Client")
+	}
+
+	orig, _ := c.getDomains("http", "api.we7.com", "80")
+	cl, ok := n.getDomains("http", "api.we7.com", "80")
+	if !ok || cl == orig || cl.credLock == orig.credLock {
+		t.Fatalf("expected a deep copy of the credentials")
+	}
+	if cl.ClientID != "original" || cl.AccessToken != "token" {
+		t.Errorf("clone lost the credentials: %+v", cl)
+	}
+
+	orig.AccessToken = "changed"
+	if cl.AccessToken != "token" {
+		t.Errorf("changing the original's token changed the clone's")
+	}
+
+	if err := n.AddDomain("example.com", DefaultCredentials()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.getDomains("http", "example.com", "80"); ok {
+		t.Errorf("credentials added to the clone leaked into the original")
+	}
+}
+
+// TestConcurrentIsolation is only meaningful with -race
+func TestConcurrentIsolation(t *testing.T) {
+	base := New()
+	creds := DefaultCredentials()
+	creds.TokenType = "MAC"
+	creds.AccessToken = "token"
+	creds.ExpiresAt = time.Now().Add(time.Hour)
+	if err := base.AddDomain("api.we7.com", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := base.Clone()
+			c.Option(Verbosity(i))
+			for j := 0; j < 20; j++ {
+				if err := c.AddDomain(fmt.Sprintf("host%d-%d.example.com", i, j), DefaultCredentials()); err != nil {
+					t.Error(err)
+					return
+				}
+				r, _ := http.NewRequest("GET", "http://api.we7.com/api/0.1/tracksInfo", nil)
+				if _, err := c.Sign(r); err != nil {
+					t.Error(err)
+					return
+				}
+				r, _ = http.NewRequest("GET", "http://api.we7.com/api/0.1/tracksInfo", nil)
+				if _, err := base.Sign(r); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	base.credentials.credsLock.RLock()
+	defer base.credentials.credsLock.RUnlock()
+	if len(base.credentials.creds) != 1 {
+		t.Errorf("expected the base client to keep 1 domain got %d", len(base.credentials.creds))
+	}
+}
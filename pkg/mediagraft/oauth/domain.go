@@ -0,0 +1,106 @@
+package oauth
+
+import "strings"
+
+// domainScope is a parsed AddDomain pattern, along with the
+// credentials to use for requests that match it
+type domainScope struct {
+	scheme   string // empty matches any scheme
+	host     string // the exact host, or the suffix (including the leading dot) for wildcards
+	port     string // empty matches any port
+	wildcard bool
+
+	creds *Credentials
+}
+
+// parseDomain parses a pattern of the form [scheme://]host[:port]
+func parseDomain(domain string) (*domainScope, error) {
+	s := &domainScope{}
+
+	rest := strings.ToLower(strings.TrimSpace(domain))
+	if i := strings.Index(rest, "://"); i >= 0 {
+		s.scheme = rest[:i]
+		rest = rest[i+3:]
+		if s.scheme == "" {
+			return nil, ErrBadDomain
+		}
+	}
+
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		s.port = rest[i+1:]
+		rest = rest[:i]
+		if s.port == "" || strings.Trim(s.port, "0123456789") != "" {
+			return nil, ErrBadDomain
+		}
+	}
+
+	if strings.HasPrefix(rest, "*.") {
+		s.wildcard = true
+		rest = rest[1:]
+	}
+
+	if rest == "" || rest == "." || strings.ContainsAny(rest, "*/") {
+		return nil, ErrBadDomain
+	}
+	s.host = rest
+
+	return s, nil
+}
+
+// String returns the canonical form of the pattern
+func (s *domainScope) String() string {
+	str := s.host
+	if s.wildcard {
+		str = "*" + str
+	}
+	if s.scheme != "" {
+		str = s.scheme + "://" + str
+	}
+	if s.port != "" {
+		str += ":" + s.port
+	}
+	return str
+}
+
+func (s *domainScope) matches(scheme, host, port string) bool {
+	if s.scheme != "" && s.scheme != strings.ToLower(scheme) {
+		return false
+	}
+	if s.port != "" && s.port != port {
+		return false
+	}
+
+	host = strings.ToLower(host)
+	if s.wildcard {
+		return strings.HasSuffix(host, s.host) && len(host) > len(s.host)
+	}
+	return host == s.host
+}
+
+// moreSpecific reports whether s should take precedence over o
+// when both match the same request
+func (s *domainScope) moreSpecific(o *domainScope) bool {
+	switch {
+	case s.wildcard != o.wildcard:
+		return !s.wildcard
+	case len(s.host) != len(o.host):
+		return len(s.host) > len(o.host)
+	case (s.port != "") != (o.port != ""):
+		return s.port != ""
+	case (s.scheme != "") != (o.scheme != ""):
+		return s.scheme != ""
+	}
+	return s.String() < o.String()
+}
+
+// authority returns the host, with the port appended if it is not
+// the default port for the scheme
+func authority(scheme, host, port string) string {
+	switch {
+	case scheme == "http" && port == "80":
+		return host
+	case scheme == "https" && port == "443":
+		return host
+	}
+	return host + ":" + port
+}
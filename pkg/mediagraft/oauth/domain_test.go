@@ -0,0 +1,85 @@
+package oauth
+
+import "testing"
+
+var testDomainPatterns = []struct {
+	in  string
+	out string
+	err error
+}{
+	{"api.we7.com", "api.we7.com", nil},
+	{"API.We7.com", "api.we7.com", nil},
+	{"api.we7.com:8080", "api.we7.com:8080", nil},
+	{"https://api.we7.com", "https://api.we7.com", nil},
+	{"http://*.stagingf.we7.com:80", "http://*.stagingf.we7.com:80", nil},
+	{"*.we7.com", "*.we7.com", nil},
+	{"", "", ErrBadDomain},
+	{"*", "", ErrBadDomain},
+	{"*.", "", ErrBadDomain},
+	{"api.*.com", "", ErrBadDomain},
+	{"api.we7.com:", "", ErrBadDomain},
+	{"api.we7.com:http", "", ErrBadDomain},
+	{"://api.we7.com", "", ErrBadDomain},
+}
+
+func TestParseDomain(t *testing.T) {
+	for i, tt := range testDomainPatterns {
+		s, err := parseDomain(tt.in)
+		if err != tt.err {
+			t.Errorf("%d. %q: expected error %v got %v\n", i, tt.in, tt.err, err)
+			continue
+		}
+		if err == nil && s.String() != tt.out {
+			t.Errorf("%d. %q: expected %s got %s\n", i, tt.in, tt.out, s.String())
+		}
+	}
+}
+
+var testDomainLookups = []struct {
+	scheme string
+	host   string
+	port   string
+	want   string // the ClientID of the matched credentials, empty for no match
+}{
+	{"http", "api.we7.com", "80", "exact"},
+	{"http", "API.WE7.COM", "80", "exact"},
+	{"http", "api.we7.com", "8080", "exact-port"},
+	{"https", "api.we7.com", "8080", "exact-scheme-port"},
+	{"https", "api.we7.com", "443", "exact"},
+	{"http", "cdn.stagingf.we7.com", "80", "staging-wildcard"},
+	{"http", "a.b.stagingf.we7.com", "80", "staging-wildcard"},
+	{"http", "stagingf.we7.com", "80", "wildcard"},
+	{"http", "www.we7.com", "80", "wildcard"},
+	{"http", "www.we7.com", "9000", "wildcard-port"},
+	{"http", "we7.com", "80", ""},
+	{"http", "example.com", "80", ""},
+}
+
+func TestDomainPrecedence(t *testing.T) {
+	c := New()
+	for _, d := range []struct{ pattern, id string }{
+		{"*.we7.com", "wildcard"},
+		{"*.we7.com:9000", "wildcard-port"},
+		{"*.stagingf.we7.com", "staging-wildcard"},
+		{"api.we7.com", "exact"},
+		{"api.we7.com:8080", "exact-port"},
+		{"https://api.we7.com:8080", "exact-scheme-port"},
+	} {
+		creds := DefaultCredentials()
+		creds.ClientID = d.id
+		if err := c.AddDomain(d.pattern, creds); err != nil {
+			t.Fatalf("AddDomain(%q) failed, %v", d.pattern, err)
+		}
+	}
+
+	for i, tt := range testDomainLookups {
+		creds, ok := c.getDomains(tt.scheme, tt.host, tt.port)
+		got := ""
+		if ok {
+			got = creds.ClientID
+		}
+		if got != tt.want {
+			t.Errorf("%d. %s://%s:%s: expected %q got %q\n", i, tt.scheme, tt.host, tt.port, tt.want, got)
+		}
+	}
+}
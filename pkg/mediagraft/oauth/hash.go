@@ -5,20 +5,40 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Signature holds the details of a signed request, it is mostly
+// useful for debugging signatures the server has rejected
+type Signature struct {
+	BaseString     string    // The normalized request string that was signed
+	KeyFingerprint string    // A fingerprint of the MAC key, see KeyFingerprint
+	Timestamp      time.Time // The timestamp included in the signature
+	Nonce          string    // The nonce included in the signature
+	Header         string    // The resulting Authorization header value
+}
+
 // Authorization generates the oauth Authorization header for a
 // given request e.g.
-//   Authorization: MAC token="IZAxYqW3gyxYMoXy7cAu33VH52slX6TfbxHEjajECUi6EOGH4dhN9Cy++tJ3iI\/WsqrSq04CM+S4Yu4R2QZBZQ==",timestamp="1312472895&",nonce="gfn2lfvn5asfo",signature="38kvZAJcf+Xq+W/Zs+7nG9ClZnI="
+//
+//	Authorization: MAC token="IZAxYqW3gyxYMoXy7cAu33VH52slX6TfbxHEjajECUi6EOGH4dhN9Cy++tJ3iI\/WsqrSq04CM+S4Yu4R2QZBZQ==",timestamp="1312472895&",nonce="gfn2lfvn5asfo",signature="38kvZAJcf+Xq+W/Zs+7nG9ClZnI="
 func (c *Credentials) Authorization(r *http.Request, t time.Time, nonce string) string {
+	return c.Sign(r, t, nonce).Header
+}
+
+// Sign signs the request, returning the normalized request string
+// alongside the Authorization header
+func (c *Credentials) Sign(r *http.Request, t time.Time, nonce string) *Signature {
 	c.credLock.RLock()
 	defer c.credLock.RUnlock()
 
@@ -36,35 +56,107 @@ func (c *Credentials) Authorization(r *http.Request, t time.Time, nonce string)
 
 	fmt.Fprintf(w, "%s\n", r.URL.Path) // Body hash, not using yet
 
-	// Must output the headers in sorted order
-	var qs []string
-	for k := range r.URL.Query() {
-		key := url.QueryEscape(k)
-		val := url.QueryEscape(r.URL.Query().Get(k))
-		qs = append(qs, key+"="+val)
-	}
-	sort.Strings(qs)
-
-	// To perform the opertion you want
-	for _, k := range qs {
-		fmt.Fprint(w, k+"\n")
+	for _, kv := range normalizeQuery(r.URL.Query(), c.Encoding) {
+		fmt.Fprint(w, kv+"\n")
 	}
 
 	w.Flush()
-	//log.Println("blah", string(b.Bytes()))
+	base := b.String()
 
 	s := hmacSha1(&b, []byte(c.Secret))
 
 	str := base64.StdEncoding.EncodeToString(s)
 
-	return fmt.Sprintf(
-		"%s token=\"%s\",timestamp=\"%d\",nonce=\"%s\",signature=\"%s\"",
-		c.TokenType,
-		c.AccessToken,
-		t.Unix(),
-		nonce,
-		str,
-	)
+	return &Signature{
+		BaseString:     base,
+		KeyFingerprint: KeyFingerprint(c.Secret),
+		Timestamp:      time.Unix(t.Unix(), 0),
+		Nonce:          nonce,
+		Header: fmt.Sprintf(
+			"%s token=\"%s\",timestamp=\"%d\",nonce=\"%s\",signature=\"%s\"",
+			c.TokenType,
+			c.AccessToken,
+			t.Unix(),
+			nonce,
+			str,
+		),
+	}
+}
+
+// KeyFingerprint returns a short, non-reversible identifier for a MAC
+// key, so keys can be compared in logs without revealing them
+func KeyFingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// QueryEncoding selects how query parameter names and values are
+// escaped in the normalized request string. It must match the
+// server's normalization or signatures will be rejected.
+type QueryEncoding int
+
+const (
+	// FormEncoding escapes as url.QueryEscape does, spaces become "+".
+	// This is what Mediagraft uses, and is the default.
+	FormEncoding QueryEncoding = iota
+	// RFC3986Encoding percent-encodes everything but the RFC 3986
+	// unreserved characters, spaces become "%20".
+	RFC3986Encoding
+)
+
+// Escape escapes s according to the encoding
+func (e QueryEncoding) Escape(s string) string {
+	switch e {
+	case RFC3986Encoding:
+		return rfc3986Escape(s)
+	default:
+		return url.QueryEscape(s)
+	}
+}
+
+func rfc3986Escape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+// normalizeQuery returns the escaped name=value pairs of the query,
+// one per value so repeated parameters are all included, sorted by
+// name and then by value
+func normalizeQuery(q url.Values, e QueryEncoding) []string {
+	type pair struct{ k, v string }
+
+	var ps []pair
+	for k, vs := range q {
+		key := e.Escape(k)
+		for _, v := range vs {
+			ps = append(ps, pair{key, e.Escape(v)})
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].k != ps[j].k {
+			return ps[i].k < ps[j].k
+		}
+		return ps[i].v < ps[j].v
+	})
+
+	qs := make([]string, len(ps))
+	for i, p := range ps {
+		qs[i] = p.k + "=" + p.v
+	}
+	return qs
 }
 
 func hmacSha1(r io.Reader, key []byte) []byte {
@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -21,8 +23,9 @@ type Request struct {
 }
 
 var testReqs = []struct {
-	r   Request
-	out string
+	r    Request
+	base string
+	out  string
 }{
 	{
 		Request{
@@ -56,6 +59,7 @@ var testReqs = []struct {
 			"http://127.0.0.1:80/api/0.1/userPlaylistsInfo?apiKey=myKey&appVersion=1&detail=full&format=xml",
 			"api.we7.com",
 		},
+		"IZAxYqW3gyxYMoXy7cAu33VH52slX6TfbxHEjajECUi6EOGH4dhN9Cy++tJ3iI\\/WsqrSq04CM+S4Yu4R2QZBZQ==\n1312471030\ngfn2lfvn5asfo\n\nGET\napi.we7.com\n80\n/api/0.1/userPlaylistsInfo\napiKey=myKey\nappVersion=1\ndetail=full\nformat=xml\n",
 		`MAC token="IZAxYqW3gyxYMoXy7cAu33VH52slX6TfbxHEjajECUi6EOGH4dhN9Cy++tJ3iI\/WsqrSq04CM+S4Yu4R2QZBZQ==",timestamp="1312471030",nonce="gfn2lfvn5asfo",signature="MkvSv/FUo/3HQvTCzPQg2Vm/lUY="`,
 	},
 	{
@@ -79,6 +83,7 @@ var testReqs = []struct {
 			"http://127.0.0.1:80/resource/1?a=2&b=1",
 			"example.com",
 		},
+		"h480djs93hd8\n137131200\ndj83hs9s\n\nGET\nexample.com\n80\n/resource/1\na=2\nb=1\n",
 		`MAC token="h480djs93hd8",timestamp="137131200",nonce="dj83hs9s",signature="YTVjyNSujYs1WsDurFnvFi4JK6o="`,
 	},
 }
@@ -100,6 +105,29 @@ func TestHashClientReq(t *testing.T) {
 	}
 }
 
+func TestSignBaseString(t *testing.T) {
+	c := DefaultCredentials()
+	c.TokenType = "MAC"
+
+	for i, tt := range testReqs {
+		c.AccessToken = tt.r.Token
+		c.Secret = tt.r.Secret
+		r, _ := http.NewRequest(tt.r.Method, tt.r.URL, nil)
+		r.Host = tt.r.Host
+
+		sig := c.Sign(r, tt.r.Time, tt.r.Nonce)
+		if sig.BaseString != tt.base {
+			t.Errorf("%d. failed: expected base string %q got %q\n", i, tt.base, sig.BaseString)
+		}
+		if sig.Header != tt.out {
+			t.Errorf("%d. failed: expected %s got %s\n", i, tt.out, sig.Header)
+		}
+		if sig.KeyFingerprint != KeyFingerprint(tt.r.Secret) || sig.KeyFingerprint == KeyFingerprint("") {
+			t.Errorf("%d. failed: bad key fingerprint %s\n", i, sig.KeyFingerprint)
+		}
+	}
+}
+
 var testHmacSha1 = []struct {
 	i string
 	k string
@@ -138,3 +166,84 @@ func mustBase64DecodeString(s string) []byte {
 	}
 	return v
 }
+
+var testNormalizeQuery = []struct {
+	query string
+	enc   QueryEncoding
+	out   []string
+}{
+	{"b=1&a=2", FormEncoding, []string{"a=2", "b=1"}},
+	{"ids=3&ids=1&ids=2", FormEncoding, []string{"ids=1", "ids=2", "ids=3"}},
+	{"type=tracks&ids=2&type=albums", FormEncoding, []string{"ids=2", "type=albums", "type=tracks"}},
+	{"a=&b", FormEncoding, []string{"a=", "b="}},
+	{"a=1&ab=0&a-b=2", FormEncoding, []string{"a=1", "a-b=2", "ab=0"}},
+	{"query=jimi+hendrix", FormEncoding, []string{"query=jimi+hendrix"}},
+	{"query=jimi%20hendrix", RFC3986Encoding, []string{"query=jimi%20hendrix"}},
+	{"query=jimi%2Bhendrix", FormEncoding, []string{"query=jimi%2Bhendrix"}},
+	{"query=jimi%2Bhendrix", RFC3986Encoding, []string{"query=jimi%2Bhendrix"}},
+	{"q=a~b*c", FormEncoding, []string{"q=a~b%2Ac"}},
+	{"q=a~b*c", RFC3986Encoding, []string{"q=a~b%2Ac"}},
+	{"q=%26%3D%2F%3F", FormEncoding, []string{"q=%26%3D%2F%3F"}},
+	{"q=Bj%C3%B6rk", FormEncoding, []string{"q=Bj%C3%B6rk"}},
+	{"q=Bj%C3%B6rk+Gu%C3%B0mundsd%C3%B3ttir", FormEncoding, []string{"q=Bj%C3%B6rk+Gu%C3%B0mundsd%C3%B3ttir"}},
+	{"q=Bj%C3%B6rk+Gu%C3%B0mundsd%C3%B3ttir", RFC3986Encoding, []string{"q=Bj%C3%B6rk%20Gu%C3%B0mundsd%C3%B3ttir"}},
+	{"q=%E5%9D%82%E6%9C%AC%E9%BE%8D%E4%B8%80", RFC3986Encoding, []string{"q=%E5%9D%82%E6%9C%AC%E9%BE%8D%E4%B8%80"}},
+	{"%C3%A9t%C3%A9=1", RFC3986Encoding, []string{"%C3%A9t%C3%A9=1"}},
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	for i, tt := range testNormalizeQuery {
+		q, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("%d. bad test query %q, %v", i, tt.query, err)
+		}
+		out := normalizeQuery(q, tt.enc)
+		if strings.Join(out, "\n") != strings.Join(tt.out, "\n") {
+			t.Errorf("%d. %q failed: expected %q got %q\n", i, tt.query, tt.out, out)
+		}
+	}
+}
+
+var testSignRepeated = []struct {
+	url  string
+	enc  QueryEncoding
+	base string
+}{
+	{
+		"http://api.we7.com/api/0.1/tracksInfo?ids=2&ids=1&apiKey=myKey",
+		FormEncoding,
+		"tok\n1312471030\nn\n\nGET\napi.we7.com\n80\n/api/0.1/tracksInfo\napiKey=myKey\nids=1\nids=2\n",
+	},
+	{
+		"http://api.we7.com/api/0.1/simpleSearch?query=Sigur+R%C3%B3s&type=artists",
+		FormEncoding,
+		"tok\n1312471030\nn\n\nGET\napi.we7.com\n80\n/api/0.1/simpleSearch\nquery=Sigur+R%C3%B3s\ntype=artists\n",
+	},
+	{
+		"http://api.we7.com/api/0.1/simpleSearch?query=Sigur+R%C3%B3s&type=artists",
+		RFC3986Encoding,
+		"tok\n1312471030\nn\n\nGET\napi.we7.com\n80\n/api/0.1/simpleSearch\nquery=Sigur%20R%C3%B3s\ntype=artists\n",
+	},
+}
+
+func TestSignRepeatedParams(t *testing.T) {
+	c := DefaultCredentials()
+	c.TokenType = "MAC"
+	c.AccessToken = "tok"
+	c.Secret = "secret"
+
+	for i, tt := range testSignRepeated {
+		c.Encoding = tt.enc
+		r, _ := http.NewRequest("GET", tt.url, nil)
+
+		sig := c.Sign(r, time.Unix(1312471030, 0), "n")
+		if sig.BaseString != tt.base {
+			t.Errorf("%d. failed: expected base string %q got %q\n", i, tt.base, sig.BaseString)
+		}
+
+		want := base64.StdEncoding.EncodeToString(hmacSha1(bytes.NewBufferString(tt.base), []byte(c.Secret)))
+		if !strings.HasSuffix(sig.Header, `signature="`+want+`"`) {
+			t.Errorf("%d. failed: expected signature %s in %s\n", i, want, sig.Header)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxNonceAttempts is the number of nonces we'll try before giving up
+// on finding one that has not been used within the current second
+const maxNonceAttempts = 8
+
+// NonceSource generates the nonces used to sign requests. Nonces must
+// be unique for a given token and timestamp, the client will reject
+// and regenerate any nonce that has already been used.
+type NonceSource interface {
+	Nonce() (string, error)
+}
+
+// NonceFunc is an adapter to allow the use of ordinary functions as a
+// NonceSource
+type NonceFunc func() (string, error)
+
+func (f NonceFunc) Nonce() (string, error) {
+	return f()
+}
+
+type randomNonces struct{}
+
+// RandomNonces returns a NonceSource that generates 96 bit nonces
+// from crypto/rand. This is the default.
+func RandomNonces() NonceSource {
+	return randomNonces{}
+}
+
+func (randomNonces) Nonce() (string, error) {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+type sequentialNonces struct {
+	lock   sync.Mutex
+	prefix string
+	n      uint64
+}
+
+// SequentialNonces returns a deterministic NonceSource that yields
+// prefix followed by an incrementing counter, starting at 1. It is
+// intended for tests.
+func SequentialNonces(prefix string) NonceSource {
+	return &sequentialNonces{prefix: prefix}
+}
+
+func (s *sequentialNonces) Nonce() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.n++
+	return s.prefix + strconv.FormatUint(s.n, 16), nil
+}
+
+// nonceLedger records the nonces used with an access token. Cloned
+// credentials share it for as long as they hold the same token.
+type nonceLedger struct {
+	lock      sync.Mutex
+	timestamp int64 // the latest timestamp used to sign a request
+	nonces    map[string]bool
+}
+
+// claimNonce picks the timestamp and nonce to sign a request made at
+// t with. Timestamps never go backwards for a given token, so we only
+// need to remember the nonces used within the latest second to
+// guarantee a (timestamp, nonce) pair is never reused.
+func (c *Credentials) claimNonce(t time.Time, src NonceSource) (time.Time, string, error) {
+	c.credLock.Lock()
+	if c.nonces == nil {
+		c.nonces = &nonceLedger{}
+	}
+	l := c.nonces
+	c.credLock.Unlock()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	ts := t.Unix()
+	switch {
+	case ts < l.timestamp:
+		ts = l.timestamp
+	case ts > l.timestamp:
+		l.timestamp = ts
+		l.nonces = nil
+	}
+	if l.nonces == nil {
+		l.nonces = make(map[string]bool)
+	}
+
+	for i := 0; i < maxNonceAttempts; i++ {
+		n, err := src.Nonce()
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		if !l.nonces[n] {
+			l.nonces[n] = true
+			return time.Unix(ts, 0), n, nil
+		}
+	}
+
+	return time.Time{}, "", ErrNonceReuse
+}
+
+// resetNonces starts a new record of the used nonces, leaving any
+// clones with the old token their own. It should be called with the
+// credLock held whenever the access token changes.
+func (c *Credentials) resetNonces() {
+	c.nonces = nil
+}
@@ -0,0 +1,193 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequentialNonces(t *testing.T) {
+	s := SequentialNonces("n")
+	for i, want := range []string{"n1", "n2", "n3"} {
+		got, err := s.Nonce()
+		if err != nil || got != want {
+			t.Errorf("%d. failed: expected %s got %s (%v)\n", i, want, got, err)
+		}
+	}
+}
+
+func TestClaimNonceConcurrent(t *testing.T) {
+	c := DefaultCredentials()
+	now := time.Unix(1312471030, 0)
+
+	// A small pool of random nonces would collide constantly, so use a
+	// source that collides on purpose every other call
+	var lock sync.Mutex
+	calls := 0
+	seq := SequentialNonces("")
+	src := NonceFunc(func() (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls++
+		if calls%2 == 0 {
+			return "1", nil
+		}
+		return seq.Nonce()
+	})
+
+	const workers, perWorker = 16, 64
+	results := make(chan string, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ts, n, err := c.claimNonce(now, src)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results <- fmt.Sprintf("%d:%s", ts.Unix(), n)
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := map[string]bool{}
+	for r := range results {
+		if seen[r] {
+			t.Fatalf("(timestamp, nonce) pair %s was reused", r)
+		}
+		seen[r] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Errorf("expected %d pairs got %d", workers*perWorker, len(seen))
+	}
+}
+
+func TestClaimNonceReuse(t *testing.T) {
+	c := DefaultCredentials()
+	now := time.Unix(1312471030, 0)
+	src := NonceFunc(func() (string, error) { return "fixed", nil })
+
+	if _, _, err := c.claimNonce(now, src); err != nil {
+		t.Fatalf("first claim failed, %v", err)
+	}
+	if _, _, err := c.claimNonce(now, src); err != ErrNonceReuse {
+		t.Errorf("expected %v got %v", ErrNonceReuse, err)
+	}
+
+	// A later second makes the nonce usable again
+	if _, _, err := c.claimNonce(now.Add(time.Second), src); err != nil {
+		t.Errorf("claim in next second failed, %v", err)
+	}
+
+	// But the clock going backwards must not
+	ts, _, err := c.claimNonce(now, src)
+	if err != ErrNonceReuse {
+		t.Errorf("expected %v got %v at %v", ErrNonceReuse, err, ts)
+	}
+}
+
+func TestCloneSharesNonces(t *testing.T) {
+	c := New()
+	creds := DefaultCredentials()
+	creds.AccessToken = "token"
+	if err := c.AddDomain("api.we7.com", creds); err != nil {
+		t.Fatal(err)
+	}
+	n := c.Clone()
+
+	orig, _ := c.getDomains("http", "api.we7.com", "80")
+	cl, _ := n.getDomains("http", "api.we7.com", "80")
+	now := time.Unix(1312471030, 0)
+	src := NonceFunc(func() (string, error) { return "fixed", nil })
+
+	if _, _, err := orig.claimNonce(now, src); err != nil {
+		t.Fatalf("first claim failed, %v", err)
+	}
+	if _, _, err := cl.claimNonce(now, src); err != ErrNonceReuse {
+		t.Errorf("expected the clone to see the original's nonce, got %v", err)
+	}
+
+	// Once the clone has its own token it has its own nonces
+	cl.credLock.Lock()
+	cl.AccessToken = "new token"
+	cl.resetNonces()
+	cl.credLock.Unlock()
+	if _, _, err := cl.claimNonce(now, src); err != nil {
+		t.Errorf("claim with a new token failed, %v", err)
+	}
+	if _, _, err := orig.claimNonce(now, src); err != ErrNonceReuse {
+		t.Errorf("expected %v got %v", ErrNonceReuse, err)
+	}
+}
+
+var authNonceRe = regexp.MustCompile(`timestamp="(\d+)",nonce="([^"]+)"`)
+
+func TestDoNoncesUnique(t *testing.T) {
+	var lock sync.Mutex
+	seen := map[string]bool{}
+	reused := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/oauth/2/token") {
+			fmt.Fprint(w, `{"token_type":"MAC","algorithm":"hmac-sha-1","secret":"s","expires_in":"3600","access_token":"t"}`)
+			return
+		}
+		m := authNonceRe.FindStringSubmatch(r.Header.Get("Authorization"))
+		if m == nil {
+			http.Error(w, "missing authorization", http.StatusUnauthorized)
+			return
+		}
+		lock.Lock()
+		if seen[m[1]+":"+m[2]] {
+			reused++
+		}
+		seen[m[1]+":"+m[2]] = true
+		lock.Unlock()
+	}))
+	defer srv.Close()
+
+	c := New()
+	c.Option(Nonces(SequentialNonces("test")))
+	creds := DefaultCredentials()
+	creds.Proto = "http"
+	if err := c.AddDomain("127.0.0.1", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				resp, err := c.Get(srv.URL + "/api/0.1/tracksInfo")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("unexpected status %s", resp.Status)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reused != 0 {
+		t.Errorf("%d (timestamp, nonce) pairs were reused", reused)
+	}
+	if len(seen) != 200 {
+		t.Errorf("expected 200 signed requests got %d", len(seen))
+	}
+}
@@ -0,0 +1,352 @@
+package oauth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrProfileNotFound = errors.New("The credentials profile was not found")
+
+// DefaultProfileName is the profile used when none is specified, it
+// may be overridden with the MEDIAGRAFT_PROFILE environment variable
+const DefaultProfileName = "default"
+
+// Profile is a named set of credentials and the domain they are used
+// within
+type Profile struct {
+	Name   string
+	Domain string // The domain pattern the credentials apply to, see AddDomain
+	Credentials
+
+	// Explicit marks the fields that were set on purpose even if they
+	// hold their zero value, so that e.g. CheckEnabled can be turned
+	// back off by a provider that takes precedence
+	Explicit ProfileField
+}
+
+// ProfileField is a set of Profile fields
+type ProfileField uint32
+
+const (
+	FieldDomain ProfileField = 1 << iota
+	FieldProto
+	FieldHost
+	FieldHostName
+	FieldTokenPath
+	FieldAuthPath
+	FieldClientID
+	FieldClientSecret
+	FieldApiKey
+	FieldUsername
+	FieldPassword
+	FieldRedirectURI
+	FieldCheckEnabled
+	FieldEncoding
+)
+
+// Provider supplies whatever credentials it knows about for the named
+// profile. Fields it does not know about are left empty and not marked
+// Explicit.
+type Provider interface {
+	Retrieve(name string) (Profile, error)
+}
+
+// ProviderFunc is an adapter to allow the use of ordinary functions
+// as a Provider
+type ProviderFunc func(name string) (Profile, error)
+
+func (f ProviderFunc) Retrieve(name string) (Profile, error) {
+	return f(name)
+}
+
+// DefaultProviders is the chain used by LoadProfile when none is
+// given: the environment, then the profiles file
+var DefaultProviders = []Provider{
+	EnvProvider{},
+	FileProvider{},
+}
+
+// ProfileName returns the profile named by MEDIAGRAFT_PROFILE, or
+// DefaultProfileName
+func ProfileName() string {
+	if n := os.Getenv("MEDIAGRAFT_PROFILE"); n != "" {
+		return n
+	}
+	return DefaultProfileName
+}
+
+// LoadProfile builds the named profile from the providers, earlier
+// providers take precedence over later ones field by field. Anything
+// left unset is taken from DefaultCredentials.
+func LoadProfile(name string, providers ...Provider) (*Profile, error) {
+	if len(providers) == 0 {
+		providers = DefaultProviders
+	}
+	if name == "" {
+		name = ProfileName()
+	}
+
+	p := Profile{Name: name}
+	for _, pr := range providers {
+		v, err := pr.Retrieve(name)
+		if err != nil {
+			return nil, err
+		}
+		p.merge(v)
+	}
+
+	d := Profile{Credentials: DefaultCredentials()}
+	p.merge(d)
+	p.credLock = d.credLock
+
+	return &p, nil
+}
+
+// AddTo adds the profile's credentials to the client for its domain,
+// or for defaultDomain if the profile doesn't specify one
+func (p *Profile) AddTo(c *Client, defaultDomain string) error {
+	d := p.Domain
+	if d == "" {
+		d = defaultDomain
+	}
+	return c.AddDomain(d, p.Credentials)
+}
+
+// APIHost returns the host[:port] of the profile's domain to send API
+// requests to, or def if the domain is unset or a wildcard pattern
+func (p *Profile) APIHost(def string) string {
+	s, err := parseDomain(p.Domain)
+	if err != nil || s.wildcard {
+		return def
+	}
+	if s.port != "" {
+		return s.host + ":" + s.port
+	}
+	return s.host
+}
+
+// isSet returns true if the field holds a value or was explicitly set
+func (p *Profile) isSet(f ProfileField, zero bool) bool {
+	return !zero || p.Explicit&f != 0
+}
+
+// merge sets any fields of p that are unset from o
+func (p *Profile) merge(o Profile) {
+	for _, f := range []struct {
+		field ProfileField
+		dst   *string
+		src   string
+	}{
+		{FieldDomain, &p.Domain, o.Domain},
+		{FieldProto, &p.Proto, o.Proto},
+		{FieldHost, &p.Host, o.Host},
+		{FieldHostName, &p.HostName, o.HostName},
+		{FieldTokenPath, &p.TokenPath, o.TokenPath},
+		{FieldAuthPath, &p.AuthPath, o.AuthPath},
+		{FieldClientID, &p.ClientID, o.ClientID},
+		{FieldClientSecret, &p.ClientSecret, o.ClientSecret},
+		{FieldApiKey, &p.ApiKey, o.ApiKey},
+		{FieldUsername, &p.Username, o.Username},
+		{FieldPassword, &p.Password, o.Password},
+		{FieldRedirectURI, &p.RedirectURI, o.RedirectURI},
+	} {
+		if !p.isSet(f.field, *f.dst == "") && o.isSet(f.field, f.src == "") {
+			*f.dst = f.src
+			p.Explicit |= o.Explicit & f.field
+		}
+	}
+	if !p.isSet(FieldCheckEnabled, !p.CheckEnabled) && o.isSet(FieldCheckEnabled, !o.CheckEnabled) {
+		p.CheckEnabled = o.CheckEnabled
+		p.Explicit |= o.Explicit & FieldCheckEnabled
+	}
+	if !p.isSet(FieldEncoding, p.Encoding == FormEncoding) && o.isSet(FieldEncoding, o.Encoding == FormEncoding) {
+		p.Encoding = o.Encoding
+		p.Explicit |= o.Explicit & FieldEncoding
+	}
+}
+
+// StaticProvider supplies explicitly given values, for any profile
+type StaticProvider Profile
+
+func (s StaticProvider) Retrieve(name string) (Profile, error) {
+	return Profile(s), nil
+}
+
+// EnvProvider supplies credentials from the OAUTH_CLIENT_ID,
+// OAUTH_CLIENT_SECRET, OAUTH_USERNAME, OAUTH_PASSWORD, and if they're
+// not empty OAUTH_CHECK_ENABLED and OAUTH_ENCODING environment
+// variables, and the domain from MEDIAGRAFT_DOMAIN, for any profile
+type EnvProvider struct{}
+
+func (EnvProvider) Retrieve(name string) (Profile, error) {
+	var p Profile
+	p.Domain = os.Getenv("MEDIAGRAFT_DOMAIN")
+	p.ClientID = os.Getenv("OAUTH_CLIENT_ID")
+	p.ClientSecret = os.Getenv("OAUTH_CLIENT_SECRET")
+	p.Username = os.Getenv("OAUTH_USERNAME")
+	p.Password = os.Getenv("OAUTH_PASSWORD")
+	for _, e := range []struct{ env, key string }{
+		{"OAUTH_CHECK_ENABLED", "check_enabled"},
+		{"OAUTH_ENCODING", "encoding"},
+	} {
+		if v := os.Getenv(e.env); v != "" {
+			if err := p.set(e.key, v); err != nil {
+				return Profile{}, fmt.Errorf("%s: %v", e.env, err)
+			}
+		}
+	}
+	return p, nil
+}
+
+// FileProvider supplies credentials from an ini style profiles file,
+// with one section per profile e.g.
+//
+//	[staging]
+//	domain = api.stagingf.we7.com
+//	client_id = myclient
+//	client_secret = s3cret
+//	username = user@example.com
+//	password = pa55word
+//
+// A missing file is only an error if a profile other than the
+// default was asked for.
+type FileProvider struct {
+	Path string // defaults to DefaultConfigPath()
+}
+
+// DefaultConfigPath returns the profiles file location, this is
+// MEDIAGRAFT_CONFIG if set, or ~/.mediagraft/config
+func DefaultConfigPath() string {
+	if p := os.Getenv("MEDIAGRAFT_CONFIG"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mediagraft", "config")
+}
+
+func (f FileProvider) Retrieve(name string) (Profile, error) {
+	path := f.Path
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	profiles, err := ReadProfiles(path)
+	if os.IsNotExist(err) {
+		profiles, err = nil, nil
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+
+	p, ok := profiles[name]
+	if !ok && name != DefaultProfileName {
+		return Profile{}, fmt.Errorf("%s: %w", name, ErrProfileNotFound)
+	}
+	return p, nil
+}
+
+// ReadProfiles parses all the profiles in the file at path. Every key
+// in the file is marked Explicit, so e.g. check_enabled = false
+// overrides a later provider. A profile may only appear once.
+func ReadProfiles(path string) (map[string]Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profiles := make(map[string]Profile)
+	var name string
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		l := strings.TrimSpace(s.Text())
+		switch {
+		case l == "", strings.HasPrefix(l, "#"), strings.HasPrefix(l, ";"):
+			continue
+		case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+			name = strings.TrimSpace(l[1 : len(l)-1])
+			if _, ok := profiles[name]; ok {
+				return nil, fmt.Errorf("%s:%d: duplicate profile [%s]", path, n, name)
+			}
+			profiles[name] = Profile{Name: name}
+			continue
+		}
+
+		i := strings.Index(l, "=")
+		if i < 0 || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected key = value within a [profile]", path, n)
+		}
+		k := strings.TrimSpace(l[:i])
+		v := strings.TrimSpace(l[i+1:])
+
+		p := profiles[name]
+		if err := p.set(k, v); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		profiles[name] = p
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// set sets the field named by a profiles file key and marks it Explicit
+func (p *Profile) set(k, v string) error {
+	var f ProfileField
+	switch k {
+	case "domain":
+		p.Domain, f = v, FieldDomain
+	case "proto":
+		p.Proto, f = v, FieldProto
+	case "host":
+		p.Host, f = v, FieldHost
+	case "host_name":
+		p.HostName, f = v, FieldHostName
+	case "token_path":
+		p.TokenPath, f = v, FieldTokenPath
+	case "auth_path":
+		p.AuthPath, f = v, FieldAuthPath
+	case "client_id":
+		p.ClientID, f = v, FieldClientID
+	case "client_secret":
+		p.ClientSecret, f = v, FieldClientSecret
+	case "api_key":
+		p.ApiKey, f = v, FieldApiKey
+	case "username":
+		p.Username, f = v, FieldUsername
+	case "password":
+		p.Password, f = v, FieldPassword
+	case "redirect_uri":
+		p.RedirectURI, f = v, FieldRedirectURI
+	case "check_enabled":
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("check_enabled: %v", err)
+		}
+		p.CheckEnabled, f = b, FieldCheckEnabled
+	case "encoding":
+		f = FieldEncoding
+		switch v {
+		case "form":
+			p.Encoding = FormEncoding
+		case "rfc3986":
+			p.Encoding = RFC3986Encoding
+		default:
+			return fmt.Errorf("unknown encoding %q", v)
+		}
+	default:
+		return fmt.Errorf("unknown key %q", k)
+	}
+	p.Explicit |= f
+	return nil
+}
@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testProfiles = `
+# comment
+[default]
+domain = api.we7.com
+client_id = default-id
+client_secret = default-secret
+
+[staging]
+domain = *.stagingf.we7.com
+client_id = staging-id
+client_secret = staging-secret
+username = user@example.com
+password = file-password
+check_enabled = true
+encoding = rfc3986
+`
+
+func writeTestProfiles(t *testing.T, s string) string {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadProfileChain(t *testing.T) {
+	path := writeTestProfiles(t, testProfiles)
+
+	t.Setenv("MEDIAGRAFT_DOMAIN", "")
+	t.Setenv("OAUTH_CLIENT_ID", "")
+	t.Setenv("OAUTH_CLIENT_SECRET", "")
+	t.Setenv("OAUTH_USERNAME", "")
+	t.Setenv("OAUTH_PASSWORD", "env-password")
+
+	p, err := LoadProfile("staging",
+		StaticProvider{Credentials: Credentials{Username: "explicit@example.com"}},
+		EnvProvider{},
+		FileProvider{Path: path},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []struct{ name, got, want string }{
+		{"Name", p.Name, "staging"},
+		{"Domain", p.Domain, "*.stagingf.we7.com"},
+		{"ClientID", p.ClientID, "staging-id"},
+		{"ClientSecret", p.ClientSecret, "staging-secret"},
+		{"Username", p.Username, "explicit@example.com"},
+		{"Password", p.Password, "env-password"},
+		{"Proto", p.Proto, "https"},
+		{"TokenPath", p.TokenPath, "/oauth/2/token"},
+	} {
+		if f.got != f.want {
+			t.Errorf("%s: expected %q got %q", f.name, f.want, f.got)
+		}
+	}
+	if !p.CheckEnabled {
+		t.Errorf("CheckEnabled: expected true")
+	}
+	if p.Encoding != RFC3986Encoding {
+		t.Errorf("Encoding: expected RFC3986Encoding got %v", p.Encoding)
+	}
+
+	c := New()
+	if err := p.AddTo(c, "api.we7.com"); err != nil {
+		t.Fatal(err)
+	}
+	if creds, ok := c.getDomains("http", "api.stagingf.we7.com", "80"); !ok || creds.ClientID != "staging-id" {
+		t.Errorf("profile was not added for its domain")
+	}
+}
+
+func TestLoadProfileMissing(t *testing.T) {
+	path := writeTestProfiles(t, testProfiles)
+
+	_, err := LoadProfile("production", FileProvider{Path: path})
+	if !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected %v got %v", ErrProfileNotFound, err)
+	}
+
+	// The default profile is optional, as is the file itself
+	missing := filepath.Join(t.TempDir(), "nothere")
+	if _, err := LoadProfile(DefaultProfileName, FileProvider{Path: missing}); err != nil {
+		t.Errorf("expected no error for a missing file got %v", err)
+	}
+	if _, err := LoadProfile("staging", FileProvider{Path: missing}); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected %v got %v", ErrProfileNotFound, err)
+	}
+}
+
+func TestReadProfilesErrors(t *testing.T) {
+	for i, s := range []string{
+		"client_id = before-section\n",
+		"[default]\nclient_id\n",
+		"[default]\nunknown = 1\n",
+		"[default]\ncheck_enabled = maybe\n",
+		"[default]\nencoding = base64\n",
+		"[default]\nclient_id = a\n[staging]\n[default]\nclient_secret = b\n",
+	} {
+		if _, err := ReadProfiles(writeTestProfiles(t, s)); err == nil {
+			t.Errorf("%d. expected an error for %q", i, s)
+		}
+	}
+}
+
+func TestLoadProfileExplicitZero(t *testing.T) {
+	path := writeTestProfiles(t, testProfiles)
+
+	t.Setenv("OAUTH_CHECK_ENABLED", "false")
+	t.Setenv("OAUTH_ENCODING", "form")
+	p, err := LoadProfile("staging", EnvProvider{}, FileProvider{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CheckEnabled || p.Encoding != FormEncoding {
+		t.Errorf("expected the environment to turn off CheckEnabled and RFC3986Encoding got %v %v", p.CheckEnabled, p.Encoding)
+	}
+
+	// Explicit empty values take precedence too, unmarked ones don't
+	p, err = LoadProfile("staging",
+		StaticProvider{Domain: "", Explicit: FieldDomain, Credentials: Credentials{CheckEnabled: false}},
+		FileProvider{Path: path},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Domain != "" || !p.CheckEnabled || p.Explicit&FieldDomain == 0 {
+		t.Errorf("expected an empty domain and CheckEnabled from the file got %q %v", p.Domain, p.CheckEnabled)
+	}
+
+	// The file marks the keys it sets
+	file := writeTestProfiles(t, "[staging]\ncheck_enabled = false\n")
+	p, err = LoadProfile("staging",
+		FileProvider{Path: file},
+		StaticProvider{Credentials: Credentials{CheckEnabled: true}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CheckEnabled {
+		t.Errorf("expected check_enabled = false to take precedence")
+	}
+
+	t.Setenv("OAUTH_ENCODING", "base64")
+	if _, err := LoadProfile("staging", EnvProvider{}); err == nil {
+		t.Errorf("expected an error for a bad OAUTH_ENCODING")
+	}
+}
+
+func TestProfileAPIHost(t *testing.T) {
+	for i, tt := range []struct{ domain, want string }{
+		{"", "def"},
+		{"api.we7.com", "api.we7.com"},
+		{"http://api.we7.com:8080", "api.we7.com:8080"},
+		{"*.stagingf.we7.com", "def"},
+	} {
+		p := Profile{Domain: tt.domain}
+		if got := p.APIHost("def"); got != tt.want {
+			t.Errorf("%d. %q: expected %q got %q", i, tt.domain, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlaylistJSON is our own JSON format, the tracks as the API sends them
+// along with any stream locations
+const PlaylistJSON PlaylistFormat = "json"
+
+// Export is a list of tracks to write as a playlist file
+type Export struct {
+	Title   string
+	Creator string
+	Image   URL
+	Tracks  []Track
+
+	// Stream locations for the tracks, see StreamLocations. Tracks
+	// without one are given a mediagraft:track:<id> URI as their
+	// location in M3U8 files and no location in other formats.
+	Locations map[TrackID]URL
+}
+
+// ExportPlaylist returns an export of the playlist
+func ExportPlaylist(p *Playlist) *Export {
+	return &Export{Title: p.Name, Creator: p.UserName, Image: p.Images.Largest(), Tracks: p.Tracks}
+}
+
+// ExportAlbum returns an export of the album's tracks
+func ExportAlbum(a *Album) *Export {
+	e := &Export{Title: a.Title, Creator: a.ArtistName(), Image: a.Images.Largest()}
+	for _, t := range a.Tracks {
+		if t.Album == nil {
+			t.Album = &AlbumRef{Id: a.Id, Title: a.Title}
+		}
+		if t.Artist == nil {
+			t.Artist = a.Artist
+		}
+		e.Tracks = append(e.Tracks, t)
+	}
+	return e
+}
+
+// ExportSearch returns an export of the tracks in a search result
+func ExportSearch(title string, r *SearchResult) *Export {
+	return &Export{Title: title, Tracks: r.Tracks}
+}
+
+// ExportStation returns an export of the station's tracks
+func ExportStation(s *Station) *Export {
+	return &Export{Title: s.Name, Image: s.Images.Largest(), Tracks: s.Tracks}
+}
+
+// StreamLocations fills in the export's stream locations using
+// StreamInfo. It stops at the first error, keeping the locations found
+// so far.
+func (c *Client) StreamLocations(e *Export, playSource string, musicFormats []string) error {
+	if e.Locations == nil {
+		e.Locations = make(map[TrackID]URL, len(e.Tracks))
+	}
+	for _, t := range e.Tracks {
+		if _, ok := e.Locations[t.Id]; ok {
+			continue
+		}
+		s, err := c.StreamInfo(t.Id, playSource, 0, musicFormats)
+		if err != nil {
+			return fmt.Errorf("track %d: %w", t.Id, err)
+		}
+		e.Locations[t.Id] = s.Location
+	}
+	return nil
+}
+
+// Write writes the export as a playlist file in format f, which may be
+// PlaylistM3U8, PlaylistXSPF, PlaylistJSPF or PlaylistJSON
+func (e *Export) Write(w io.Writer, f PlaylistFormat) error {
+	switch f {
+	case PlaylistM3U8:
+		return e.writeM3U8(w)
+	case PlaylistXSPF:
+		return e.writeXSPF(w)
+	case PlaylistJSPF:
+		return e.writeJSPF(w)
+	case PlaylistJSON:
+		return e.writeJSON(w)
+	}
+	return fmt.Errorf("%w: can't export %q", ErrUnknownPlaylistFormat, f)
+}
+
+func (e *Export) location(t *Track) URL {
+	return e.Locations[t.Id]
+}
+
+func trackURI(t *Track) string {
+	return "mediagraft:track:" + strconv.Itoa(int(t.Id))
+}
+
+func (e *Export) writeM3U8(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	if e.Title != "" {
+		fmt.Fprintf(&b, "#PLAYLIST:%s\n", oneLine(e.Title))
+	}
+	for i := range e.Tracks {
+		t := &e.Tracks[i]
+
+		secs := -1
+		if t.Duration > 0 {
+			secs = int((t.Duration + time.Second/2) / time.Second)
+		}
+		name := t.Title
+		if a := t.ArtistName(); a != "" {
+			name = a + " - " + name
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n", secs, oneLine(name))
+		if a := t.AlbumTitle(); a != "" {
+			fmt.Fprintf(&b, "#EXTALB:%s\n", oneLine(a))
+		}
+
+		loc := string(e.location(t))
+		if loc == "" {
+			loc = trackURI(t)
+		}
+		b.WriteString(loc + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (e *Export) writeXSPF(w io.Writer) error {
+	p := xspfPlaylist{
+		Xmlns:   xspfNamespace,
+		Version: "1",
+		Title:   e.Title,
+		Creator: e.Creator,
+		Image:   string(e.Image),
+	}
+	for i := range e.Tracks {
+		t := &e.Tracks[i]
+		xt := xspfTrack{
+			Title:      t.Title,
+			Creator:    t.ArtistName(),
+			Album:      t.AlbumTitle(),
+			Image:      string(t.Images.Largest()),
+			Duration:   int64(t.Duration / time.Millisecond),
+			TrackNum:   t.TrackNumber,
+			Identifier: trackURI(t),
+		}
+		if loc := e.location(t); loc != "" {
+			xt.Location = []string{string(loc)}
+		}
+		p.Tracks = append(p.Tracks, xt)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (e *Export) writeJSPF(w io.Writer) error {
+	var p jspfPlaylist
+	p.Playlist.Title = e.Title
+	p.Playlist.Creator = e.Creator
+	p.Playlist.Image = string(e.Image)
+	p.Playlist.Tracks = []jspfTrack{}
+	for i := range e.Tracks {
+		t := &e.Tracks[i]
+		jt := jspfTrack{
+			Title:      t.Title,
+			Creator:    t.ArtistName(),
+			Album:      t.AlbumTitle(),
+			Image:      string(t.Images.Largest()),
+			Duration:   int64(t.Duration / time.Millisecond),
+			TrackNum:   t.TrackNumber,
+			Identifier: []string{trackURI(t)},
+		}
+		if loc := e.location(t); loc != "" {
+			jt.Location = []string{string(loc)}
+		}
+		p.Playlist.Tracks = append(p.Playlist.Tracks, jt)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(p)
+}
+
+type exportJSON struct {
+	Title     string         `json:"title,omitempty"`
+	Creator   string         `json:"creator,omitempty"`
+	Image     URL            `json:"image,omitempty"`
+	Tracks    []Track        `json:"tracks"`
+	Locations map[string]URL `json:"streamLocations,omitempty"` // Keyed by track id
+}
+
+func (e *Export) writeJSON(w io.Writer) error {
+	j := exportJSON{Title: e.Title, Creator: e.Creator, Image: e.Image, Tracks: e.Tracks}
+	if j.Tracks == nil {
+		j.Tracks = []Track{}
+	}
+	for id, loc := range e.Locations {
+		if j.Locations == nil {
+			j.Locations = make(map[string]URL, len(e.Locations))
+		}
+		j.Locations[strconv.Itoa(int(id))] = loc
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(j)
+}
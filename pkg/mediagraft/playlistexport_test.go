@@ -0,0 +1,148 @@
+package mediagraft
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testExport() *Export {
+	a := &Album{
+		Id:     500,
+		Title:  "Are You Experienced",
+		Artist: &ArtistRef{Id: 42, Name: "Jimi Hendrix"},
+		Images: Images{"100x100": "http://img.example.com/small.jpg", "300x300": "http://img.example.com/big.jpg"},
+		Tracks: []Track{
+			{Id: 1, Title: "Purple Haze", Duration: 171 * time.Second, TrackNumber: 1},
+			{Id: 2, Title: "Hey Joe", Duration: 210*time.Second + 400*time.Millisecond, TrackNumber: 2},
+		},
+	}
+	return ExportAlbum(a)
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	want := []MatchRequest{
+		{Title: "Purple Haze", Artist: "Jimi Hendrix", Album: "Are You Experienced", Duration: 171 * time.Second},
+		{Title: "Hey Joe", Artist: "Jimi Hendrix", Album: "Are You Experienced", Duration: 210*time.Second + 400*time.Millisecond},
+	}
+	for i, f := range []PlaylistFormat{PlaylistM3U8, PlaylistXSPF, PlaylistJSPF} {
+		var b bytes.Buffer
+		if err := testExport().Write(&b, f); err != nil {
+			t.Fatalf("%d. %v", i, err)
+		}
+		reqs, err := ParsePlaylist(&b, f)
+		if err != nil {
+			t.Fatalf("%d. %v", i, err)
+		}
+		want := append([]MatchRequest(nil), want...)
+		if f == PlaylistM3U8 {
+			// EXTINF durations are whole seconds
+			want[1].Duration = 210 * time.Second
+		}
+		if !reflect.DeepEqual(reqs, want) {
+			t.Errorf("%d. %s round tripped to %+v", i, f, reqs)
+		}
+	}
+}
+
+func TestExportM3U8(t *testing.T) {
+	e := testExport()
+	e.Locations = map[TrackID]URL{2: "http://stream.example.com/2.mp3"}
+
+	var b bytes.Buffer
+	if err := e.Write(&b, PlaylistM3U8); err != nil {
+		t.Fatal(err)
+	}
+	want := `#EXTM3U
+#PLAYLIST:Are You Experienced
+#EXTINF:171,Jimi Hendrix - Purple Haze
+#EXTALB:Are You Experienced
+mediagraft:track:1
+#EXTINF:210,Jimi Hendrix - Hey Joe
+#EXTALB:Are You Experienced
+http://stream.example.com/2.mp3
+`
+	if b.String() != want {
+		t.Errorf("exported\n%s\nexpected\n%s", b.String(), want)
+	}
+}
+
+func TestExportXSPF(t *testing.T) {
+	var b bytes.Buffer
+	if err := testExport().Write(&b, PlaylistXSPF); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<playlist xmlns="http://xspf.org/ns/0/" version="1">`,
+		`<creator>Jimi Hendrix</creator>`,
+		`<image>http://img.example.com/big.jpg</image>`,
+		`<identifier>mediagraft:track:1</identifier>`,
+		`<trackNum>2</trackNum>`,
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("expected %s in\n%s", s, b.String())
+		}
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	e := testExport()
+	e.Locations = map[TrackID]URL{1: "http://stream.example.com/1.mp3"}
+
+	var b bytes.Buffer
+	if err := e.Write(&b, PlaylistJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var got exportJSON
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != e.Title || got.Creator != "Jimi Hendrix" || !reflect.DeepEqual(got.Tracks, e.Tracks) {
+		t.Errorf("unexpected export %+v", got)
+	}
+	if got.Locations["1"] != "http://stream.example.com/1.mp3" {
+		t.Errorf("unexpected locations %v", got.Locations)
+	}
+}
+
+func TestStreamLocations(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"streaming/streamInfoWithOAuth": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("playSource") != "PLAYLIST" || q.Get("musicFormats") != "MP3" {
+				t.Errorf("unexpected query %v", q)
+			}
+			id := q.Get("trackId")
+			w.Write([]byte(`{"trackId": "` + id + `", "streamLocation": "http://stream.example.com/` + id + `.mp3"}`))
+		},
+	})
+
+	e := testExport()
+	if err := c.StreamLocations(e, "PLAYLIST", []string{"MP3"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[TrackID]URL{1: "http://stream.example.com/1.mp3", 2: "http://stream.example.com/2.mp3"}
+	if !reflect.DeepEqual(e.Locations, want) {
+		t.Errorf("unexpected locations %v", e.Locations)
+	}
+}
+
+func TestImagesLargest(t *testing.T) {
+	for i, tc := range []struct {
+		images Images
+		want   URL
+	}{
+		{nil, ""},
+		{Images{"100x100": "a", "640x480": "b", "300x300": "c"}, "b"},
+		{Images{"100x100": "a", "original": "o"}, "o"},
+	} {
+		if got := tc.images.Largest(); got != tc.want {
+			t.Errorf("%d. got %q expected %q", i, got, tc.want)
+		}
+	}
+}
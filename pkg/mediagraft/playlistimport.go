@@ -0,0 +1,316 @@
+package mediagraft
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PlaylistFormat is a playlist file format
+type PlaylistFormat string
+
+const (
+	PlaylistM3U  PlaylistFormat = "m3u"
+	PlaylistM3U8 PlaylistFormat = "m3u8"
+	PlaylistXSPF PlaylistFormat = "xspf"
+	PlaylistJSPF PlaylistFormat = "jspf"
+	PlaylistCSV  PlaylistFormat = "csv"
+)
+
+var (
+	ErrUnknownPlaylistFormat = errors.New("Unknown playlist format")
+	ErrNoTitleColumn         = errors.New("The CSV playlist has no title column")
+)
+
+// PlaylistFormatFor returns the format of a playlist file from its
+// extension
+func PlaylistFormatFor(name string) (PlaylistFormat, error) {
+	f := PlaylistFormat(strings.ToLower(strings.TrimPrefix(path.Ext(name), ".")))
+	switch f {
+	case PlaylistM3U, PlaylistM3U8, PlaylistXSPF, PlaylistJSPF, PlaylistCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownPlaylistFormat, name)
+}
+
+// ParsePlaylist reads the tracks of a playlist file in format f
+func ParsePlaylist(r io.Reader, f PlaylistFormat) ([]MatchRequest, error) {
+	switch f {
+	case PlaylistM3U, PlaylistM3U8:
+		return parseM3U(r, f == PlaylistM3U)
+	case PlaylistXSPF:
+		return parseXSPF(r)
+	case PlaylistJSPF:
+		return parseJSPF(r)
+	case PlaylistCSV:
+		return parseCSV(r)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownPlaylistFormat, f)
+}
+
+// PlaylistImport is the result of importing a playlist
+type PlaylistImport struct {
+	Tracks []Track      // The matched tracks in playlist order
+	Report *MatchReport // The matches for every track in the file
+}
+
+// ImportPlaylist parses a playlist file in format f and matches its
+// tracks. Tracks that couldn't be matched are left out of Tracks and
+// can be found with Report.Unmatched.
+func (m *Matcher) ImportPlaylist(r io.Reader, f PlaylistFormat) (*PlaylistImport, error) {
+	reqs, err := ParsePlaylist(r, f)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &PlaylistImport{Report: m.Match(reqs)}
+	for _, match := range imp.Report.Matches {
+		if match.Matched() {
+			imp.Tracks = append(imp.Tracks, *match.Track)
+		}
+	}
+	return imp, nil
+}
+
+// parseM3U reads an extended or plain M3U playlist. Tracks without an
+// EXTINF line are named after their file, as "Artist - Title" if it
+// has that form. Plain .m3u files may be Latin-1 rather than UTF-8.
+func parseM3U(r io.Reader, latin1 bool) ([]MatchRequest, error) {
+	var (
+		reqs   []MatchRequest
+		cur    MatchRequest
+		extinf bool
+	)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if latin1 && !utf8.ValidString(line) {
+			line = fromLatin1(line)
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:seconds[ attributes],Artist - Title
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			secs, name, _ := strings.Cut(info, ",")
+			if f := strings.Fields(secs); len(f) != 0 {
+				if d, err := strconv.ParseFloat(f[0], 64); err == nil && d > 0 {
+					cur.Duration = time.Duration(d * float64(time.Second))
+				}
+			}
+			artist, title := splitArtistTitle(name)
+			if cur.Artist == "" {
+				cur.Artist = artist
+			}
+			cur.Title = title
+			extinf = true
+		case strings.HasPrefix(line, "#EXTART:"):
+			cur.Artist = strings.TrimSpace(strings.TrimPrefix(line, "#EXTART:"))
+		case strings.HasPrefix(line, "#EXTALB:"):
+			cur.Album = strings.TrimSpace(strings.TrimPrefix(line, "#EXTALB:"))
+		case strings.HasPrefix(line, "#"):
+			// Other directives and comments
+		default:
+			if !extinf {
+				name := path.Base(strings.ReplaceAll(line, "\\", "/"))
+				cur.Artist, cur.Title = splitArtistTitle(strings.TrimSuffix(name, path.Ext(name)))
+			}
+			if cur.Title != "" {
+				reqs = append(reqs, cur)
+			}
+			cur, extinf = MatchRequest{}, false
+		}
+	}
+	return reqs, s.Err()
+}
+
+// splitArtistTitle splits "Artist - Title", if there's no artist it
+// returns the whole name as the title
+func splitArtistTitle(name string) (artist, title string) {
+	name = strings.TrimSpace(name)
+	if a, t, ok := strings.Cut(name, " - "); ok {
+		return strings.TrimSpace(a), strings.TrimSpace(t)
+	}
+	return "", name
+}
+
+func fromLatin1(s string) string {
+	rs := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		rs[i] = rune(s[i])
+	}
+	return string(rs)
+}
+
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Xmlns   string      `xml:"xmlns,attr,omitempty"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Creator string      `xml:"creator,omitempty"`
+	Image   string      `xml:"image,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location   []string `xml:"location,omitempty"`
+	Identifier string   `xml:"identifier,omitempty"`
+	Title      string   `xml:"title,omitempty"`
+	Creator    string   `xml:"creator,omitempty"`
+	Image      string   `xml:"image,omitempty"`
+	Album      string   `xml:"album,omitempty"`
+	TrackNum   int      `xml:"trackNum,omitempty"`
+	Duration   int64    `xml:"duration,omitempty"` // Milliseconds
+}
+
+func parseXSPF(r io.Reader) ([]MatchRequest, error) {
+	var p xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+
+	var reqs []MatchRequest
+	for _, t := range p.Tracks {
+		if t.Title == "" {
+			continue
+		}
+		reqs = append(reqs, MatchRequest{
+			Title:    t.Title,
+			Artist:   t.Creator,
+			Album:    t.Album,
+			Duration: time.Duration(t.Duration) * time.Millisecond,
+		})
+	}
+	return reqs, nil
+}
+
+type jspfPlaylist struct {
+	Playlist struct {
+		Title   string      `json:"title,omitempty"`
+		Creator string      `json:"creator,omitempty"`
+		Image   string      `json:"image,omitempty"`
+		Tracks  []jspfTrack `json:"track"`
+	} `json:"playlist"`
+}
+
+type jspfTrack struct {
+	Location   []string `json:"location,omitempty"`
+	Identifier []string `json:"identifier,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Creator    string   `json:"creator,omitempty"`
+	Image      string   `json:"image,omitempty"`
+	Album      string   `json:"album,omitempty"`
+	TrackNum   int      `json:"trackNum,omitempty"`
+	Duration   int64    `json:"duration,omitempty"` // Milliseconds
+}
+
+func parseJSPF(r io.Reader) ([]MatchRequest, error) {
+	var p jspfPlaylist
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+
+	var reqs []MatchRequest
+	for _, t := range p.Playlist.Tracks {
+		if t.Title == "" {
+			continue
+		}
+		reqs = append(reqs, MatchRequest{
+			Title:    t.Title,
+			Artist:   t.Creator,
+			Album:    t.Album,
+			Duration: time.Duration(t.Duration) * time.Millisecond,
+		})
+	}
+	return reqs, nil
+}
+
+// csvColumns lists the header names, lower cased without spaces or
+// underscores, each CSV column is found by
+var csvColumns = map[string][]string{
+	"title":      {"title", "track", "trackname", "name", "song"},
+	"artist":     {"artist", "artistname", "creator"},
+	"album":      {"album", "albumname"},
+	"duration":   {"duration", "length", "time"},
+	"durationms": {"durationms"},
+}
+
+// parseCSV reads a CSV playlist with a header row. The columns are
+// found by name: title (or track or name), artist (or creator), album,
+// and duration in seconds or [h:]m:ss (or duration_ms/durationMs).
+func parseCSV(r io.Reader) ([]MatchRequest, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		h = strings.NewReplacer("_", "", " ", "").Replace(h)
+		for name, aliases := range csvColumns {
+			for _, a := range aliases {
+				if _, ok := col[name]; !ok && h == a {
+					col[name] = i
+				}
+			}
+		}
+	}
+	if _, ok := col["title"]; !ok {
+		return nil, ErrNoTitleColumn
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var reqs []MatchRequest
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return reqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		req := MatchRequest{
+			Title:  field(row, "title"),
+			Artist: field(row, "artist"),
+			Album:  field(row, "album"),
+		}
+		if req.Title == "" {
+			continue
+		}
+		if ms := field(row, "durationms"); ms != "" {
+			if req.Duration, err = parseDuration(ms, time.Millisecond); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		} else if s := field(row, "duration"); s != "" {
+			if req.Duration, err = parseDuration(s, time.Second); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		reqs = append(reqs, req)
+	}
+}
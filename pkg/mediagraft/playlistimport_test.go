@@ -0,0 +1,116 @@
+package mediagraft
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var hendrixPlaylist = []MatchRequest{
+	{Title: "Purple Haze", Artist: "Jimi Hendrix", Album: "Are You Experienced", Duration: 171 * time.Second},
+	{Title: "Hey Joe", Artist: "Jimi Hendrix", Album: "Are You Experienced", Duration: 210 * time.Second},
+	{Title: "Little Wing", Artist: "Jimi Hendrix"},
+}
+
+func TestParsePlaylistFixtures(t *testing.T) {
+	for i, name := range []string{"playlist.m3u8", "playlist.xspf", "playlist.jspf", "playlist.csv"} {
+		f, err := PlaylistFormatFor(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in, err := os.Open("testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqs, err := ParsePlaylist(in, f)
+		in.Close()
+		if err != nil {
+			t.Errorf("%d. %s: %v", i, name, err)
+			continue
+		}
+		if !reflect.DeepEqual(reqs, hendrixPlaylist) {
+			t.Errorf("%d. %s: parsed %+v", i, name, reqs)
+		}
+	}
+}
+
+func TestParseM3U(t *testing.T) {
+	for i, tc := range []struct {
+		f    PlaylistFormat
+		in   string
+		want []MatchRequest
+	}{
+		{
+			PlaylistM3U,
+			"/music/Jimi Hendrix - Purple Haze.mp3\r\n/music/Foxy Lady.flac\r\n",
+			[]MatchRequest{{Title: "Purple Haze", Artist: "Jimi Hendrix"}, {Title: "Foxy Lady"}},
+		},
+		{
+			PlaylistM3U,
+			"#EXTM3U\n#EXTINF:200,Beyonc\xe9 - Halo\nhalo.mp3\n",
+			[]MatchRequest{{Title: "Halo", Artist: "Beyoncé", Duration: 200 * time.Second}},
+		},
+		{
+			PlaylistM3U8,
+			"\ufeff#EXTM3U\n# a comment\n#EXTINF:12.5,Intro\nintro.mp3\n",
+			[]MatchRequest{{Title: "Intro", Duration: 12500 * time.Millisecond}},
+		},
+	} {
+		reqs, err := ParsePlaylist(strings.NewReader(tc.in), tc.f)
+		if err != nil {
+			t.Errorf("%d. %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(reqs, tc.want) {
+			t.Errorf("%d. parsed %+v expected %+v", i, reqs, tc.want)
+		}
+	}
+}
+
+func TestParsePlaylistErrors(t *testing.T) {
+	if _, err := PlaylistFormatFor("playlist.wpl"); !errors.Is(err, ErrUnknownPlaylistFormat) {
+		t.Errorf("expected ErrUnknownPlaylistFormat got %v", err)
+	}
+	if _, err := ParsePlaylist(strings.NewReader("artist,album\na,b\n"), PlaylistCSV); err != ErrNoTitleColumn {
+		t.Errorf("expected ErrNoTitleColumn got %v", err)
+	}
+	if _, err := ParsePlaylist(strings.NewReader("title,duration\na,soon\n"), PlaylistCSV); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a bad duration error got %v", err)
+	}
+}
+
+func TestImportPlaylist(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"findMatch": func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("title") {
+			case "Purple Haze":
+				w.Write([]byte(`{"tracks": [{"trackId": "1", "trackTitle": "Purple Haze", "artistName": "Jimi Hendrix", "duration": "171"}]}`))
+			case "Hey Joe":
+				w.Write([]byte(`{"tracks": [{"trackId": "2", "trackTitle": "Hey Joe", "artistName": "Jimi Hendrix", "duration": "210"}]}`))
+			default:
+				w.Write([]byte(`{"tracks": []}`))
+			}
+		},
+	})
+
+	in, err := os.Open("testdata/playlist.xspf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	imp, err := NewMatcher(c, MatchInterval(0), MatchFallback(false)).ImportPlaylist(in, PlaylistXSPF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imp.Tracks) != 2 || imp.Tracks[0].Id != 1 || imp.Tracks[1].Id != 2 {
+		t.Errorf("unexpected tracks %+v", imp.Tracks)
+	}
+	if u := imp.Report.Unmatched(); len(u) != 1 || u[0].Request.Title != "Little Wing" {
+		t.Errorf("unexpected unmatched %+v", u)
+	}
+}
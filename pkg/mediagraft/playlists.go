@@ -0,0 +1,164 @@
+package mediagraft
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrPlaylistConflict = errors.New("The playlist has been changed since it was fetched")
+
+var playlistsLookup = entityLookup[Playlist]{
+	method:  "playlistsInfo",
+	idParam: "ids",
+	id:      func(p *Playlist) int { return int(p.Id) },
+}
+
+// UserPlaylists returns the signed in user's playlists, with their
+// tracks if tracks is true
+func (c *Client) UserPlaylists(tracks bool) ([]Playlist, error) {
+	args := &url.Values{}
+	if tracks {
+		args.Set("detail", "full")
+	} else {
+		args.Set("detail", "summary")
+	}
+
+	r, err := c.Call("GET", "userPlaylistsInfo", args, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return nil, fmt.Errorf("userPlaylistsInfo: %s", r.Status)
+	}
+
+	var ps []Playlist
+	err = c.decode("userPlaylistsInfo", r.Body, &ps)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// PlaylistsInfo returns the playlists with the given ids and their
+// tracks, in the same order. If some playlists were not found the
+// others are returned along with a *PartialResultError.
+func (c *Client) PlaylistsInfo(playlistId ...PlaylistID) ([]Playlist, error) {
+	return lookup(c, playlistsLookup, intIDs(playlistId))
+}
+
+// GetPlaylist returns the playlist and its tracks
+func (c *Client) GetPlaylist(id PlaylistID) (*Playlist, error) {
+	ps, err := c.PlaylistsInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	return &ps[0], nil
+}
+
+// The calls that change a playlist send the version of the playlist
+// they were made against, and fail with ErrPlaylistConflict if it has
+// been changed since. On success the playlist is updated in place,
+// including its new version, so further changes can be made with it.
+//
+// The API doesn't document these calls, their names, parameters and
+// the 409 Conflict returned for a stale version are assumptions, as is
+// playlistsInfo.
+
+// CreatePlaylist creates an empty playlist for the signed in user
+func (c *Client) CreatePlaylist(name, description string) (*Playlist, error) {
+	args := &url.Values{}
+	args.Set("playlistName", name)
+	args.Set("playlistDescription", description)
+
+	var p Playlist
+	if err := c.changePlaylist("createPlaylist", args, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// RenamePlaylist changes the name of the playlist
+func (c *Client) RenamePlaylist(p *Playlist, name string) error {
+	args := playlistArgs(p)
+	args.Set("playlistName", name)
+	return c.changePlaylist("renamePlaylist", args, p)
+}
+
+// DeletePlaylist deletes the playlist
+func (c *Client) DeletePlaylist(p *Playlist) error {
+	return c.changePlaylist("deletePlaylist", playlistArgs(p), nil)
+}
+
+// AddPlaylistTracks inserts the tracks at position, from 0, or appends
+// them if position is negative
+func (c *Client) AddPlaylistTracks(p *Playlist, position int, trackId ...TrackID) error {
+	args := playlistArgs(p)
+	args.Set("trackIds", joinIDs(trackId))
+	if position >= 0 {
+		args.Set("position", strconv.Itoa(position))
+	}
+	return c.changePlaylist("addTracksToPlaylist", args, p)
+}
+
+// RemovePlaylistTracks removes the tracks at the positions, from 0
+func (c *Client) RemovePlaylistTracks(p *Playlist, position ...int) error {
+	args := playlistArgs(p)
+	args.Set("positions", joinIDs(position))
+	return c.changePlaylist("removeTracksFromPlaylist", args, p)
+}
+
+// MovePlaylistTrack moves the track at position from to position to
+func (c *Client) MovePlaylistTrack(p *Playlist, from, to int) error {
+	args := playlistArgs(p)
+	args.Set("from", strconv.Itoa(from))
+	args.Set("to", strconv.Itoa(to))
+	return c.changePlaylist("reorderPlaylistTracks", args, p)
+}
+
+func playlistArgs(p *Playlist) *url.Values {
+	args := &url.Values{}
+	args.Set("playlistId", strconv.Itoa(int(p.Id)))
+	args.Set("playlistVersion", strconv.Itoa(p.Version))
+	return args
+}
+
+// changePlaylist makes a call that changes a playlist, decoding the
+// updated playlist into p unless it's nil
+func (c *Client) changePlaylist(method string, args *url.Values, p *Playlist) error {
+	r, err := c.Call("POST", method, args, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	switch {
+	case r.StatusCode == http.StatusConflict:
+		return ErrPlaylistConflict
+	case r.StatusCode < 200 || r.StatusCode > 299:
+		return fmt.Errorf("%s: %s", method, r.Status)
+	case p == nil:
+		return nil
+	}
+
+	var updated Playlist
+	if err := c.decode(method, r.Body, &updated); err != nil {
+		return err
+	}
+	*p = updated
+	return nil
+}
+
+func joinIDs[T ~int](ids []T) string {
+	ss := make([]string, len(ids))
+	for i, id := range ids {
+		ss[i] = strconv.Itoa(int(id))
+	}
+	return strings.Join(ss, ",")
+}
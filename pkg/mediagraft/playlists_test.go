@@ -0,0 +1,139 @@
+package mediagraft_test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	mg "github.com/we7/go-mediagraft/pkg/mediagraft"
+	"github.com/we7/go-mediagraft/pkg/mediagraft/mediagrafttest"
+)
+
+func playlistTrackIds(p *mg.Playlist) []mg.TrackID {
+	ids := []mg.TrackID{}
+	for _, t := range p.Tracks {
+		ids = append(ids, t.Id)
+	}
+	return ids
+}
+
+func TestPlaylists(t *testing.T) {
+	c := mediagrafttest.NewClient(t, (&mediagrafttest.Playlists{}).Handlers())
+
+	p, err := c.CreatePlaylist("Mix", "Some tracks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Id == 0 || p.Name != "Mix" || p.Description != "Some tracks" || p.Version != 1 {
+		t.Fatalf("unexpected playlist %+v", p)
+	}
+
+	tests := []struct {
+		change  func() error
+		name    string
+		version int
+		tracks  []mg.TrackID
+	}{
+		{func() error { return c.AddPlaylistTracks(p, -1, 1, 2, 3) }, "Mix", 2, []mg.TrackID{1, 2, 3}},
+		{func() error { return c.AddPlaylistTracks(p, 1, 4) }, "Mix", 3, []mg.TrackID{1, 4, 2, 3}},
+		{func() error { return c.MovePlaylistTrack(p, 0, 3) }, "Mix", 4, []mg.TrackID{4, 2, 3, 1}},
+		{func() error { return c.MovePlaylistTrack(p, 2, 0) }, "Mix", 5, []mg.TrackID{3, 4, 2, 1}},
+		{func() error { return c.RemovePlaylistTracks(p, 0, 2) }, "Mix", 6, []mg.TrackID{4, 1}},
+		{func() error { return c.RenamePlaylist(p, "Best of") }, "Best of", 7, []mg.TrackID{4, 1}},
+	}
+	for i, test := range tests {
+		if err := test.change(); err != nil {
+			t.Fatalf("%d. %v", i, err)
+		}
+		if p.Name != test.name || p.Version != test.version {
+			t.Errorf("%d. expected %q version %d got %q version %d", i, test.name, test.version, p.Name, p.Version)
+		}
+		if got := playlistTrackIds(p); !reflect.DeepEqual(got, test.tracks) {
+			t.Errorf("%d. expected tracks %v got %v", i, test.tracks, got)
+		}
+	}
+
+	got, err := c.GetPlaylist(p.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != p.Version || !reflect.DeepEqual(playlistTrackIds(got), playlistTrackIds(p)) {
+		t.Errorf("expected %+v got %+v", p, got)
+	}
+
+	ps, err := c.UserPlaylists(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 || ps[0].Id != p.Id || len(ps[0].Tracks) != 0 {
+		t.Errorf("expected the playlist without tracks got %+v", ps)
+	}
+	ps, err = c.UserPlaylists(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 || len(ps[0].Tracks) != 2 {
+		t.Errorf("expected the playlist with tracks got %+v", ps)
+	}
+
+	if err := c.DeletePlaylist(p); err != nil {
+		t.Fatal(err)
+	}
+	if ps, err := c.UserPlaylists(false); err != nil || len(ps) != 0 {
+		t.Errorf("expected no playlists got %+v, %v", ps, err)
+	}
+	var perr *mg.PartialResultError
+	if _, err := c.GetPlaylist(p.Id); !errors.As(err, &perr) {
+		t.Errorf("expected a *mg.PartialResultError for the deleted playlist got %v", err)
+	}
+}
+
+func TestPlaylistConflict(t *testing.T) {
+	c := mediagrafttest.NewClient(t, (&mediagrafttest.Playlists{}).Handlers())
+
+	p, err := c.CreatePlaylist("Mix", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := *p
+
+	if err := c.AddPlaylistTracks(p, -1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenamePlaylist(&stale, "Other"); err != mg.ErrPlaylistConflict {
+		t.Errorf("expected mg.ErrPlaylistConflict got %v", err)
+	}
+	if stale.Name != "Mix" || stale.Version != 1 {
+		t.Errorf("expected the stale playlist to be unchanged got %+v", stale)
+	}
+	if err := c.DeletePlaylist(&stale); err != mg.ErrPlaylistConflict {
+		t.Errorf("expected mg.ErrPlaylistConflict got %v", err)
+	}
+
+	// Refetching gets the current version
+	fresh, err := c.GetPlaylist(p.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenamePlaylist(fresh, "Other"); err != nil {
+		t.Errorf("expected the rename to succeed got %v", err)
+	}
+
+	if err := c.MovePlaylistTrack(fresh, 0, 5); err == nil || err == mg.ErrPlaylistConflict {
+		t.Errorf("expected a bad request error got %v", err)
+	}
+}
+
+func TestUserPlaylistsStatus(t *testing.T) {
+	c := mediagrafttest.NewClient(t, map[string]http.HandlerFunc{
+		"userPlaylistsInfo": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorised", http.StatusUnauthorized)
+		},
+	})
+
+	if ps, err := c.UserPlaylists(false); err == nil || !strings.Contains(err.Error(), "401") || ps != nil {
+		t.Errorf("expected a 401 error and no playlists got %v, %v", ps, err)
+	}
+}
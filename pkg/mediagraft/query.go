@@ -0,0 +1,175 @@
+package mediagraft
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmptyQuery            = errors.New("The search query is empty")
+	ErrNoSearchTypes         = errors.New("No result types were given to search for")
+	ErrUnknownSearchType     = errors.New("Unknown search result type")
+	ErrBadOrder              = errors.New("Malformed search order")
+	ErrUnknownDirection      = errors.New("Unknown search order direction")
+	ErrDirectionWithoutOrder = errors.New("A search order direction was given without an order")
+	ErrBadLimits             = errors.New("The limits are negative or end before they begin")
+	ErrArtistFilter          = errors.New("Artist ids can only restrict searches for tracks, track versions and albums")
+)
+
+// Query builds a search, checking the options make sense together
+// before it's sent:
+//
+//	q := NewQuery("purple haze", SearchTracks).OrderBy(OrderPopularity).Descending().Limit(0, 10)
+//	res, err := c.Search(q)
+type Query struct {
+	text  string
+	types []SearchType
+	opts  []searchOpt
+}
+
+// NewQuery returns a query searching for text in the result types
+func NewQuery(text string, types ...SearchType) *Query {
+	return &Query{text: text, types: types}
+}
+
+// Types adds result types to search for
+func (q *Query) Types(types ...SearchType) *Query {
+	q.types = append(q.types, types...)
+	return q
+}
+
+// With adds search options to the query
+func (q *Query) With(opts ...searchOpt) *Query {
+	q.opts = append(q.opts, opts...)
+	return q
+}
+
+// OrderBy sorts the results by o
+func (q *Query) OrderBy(o SearchOrder) *Query {
+	return q.With(Order(o))
+}
+
+// Ascending sorts the results in ascending order
+func (q *Query) Ascending() *Query {
+	return q.With(OrderDirection(Ascending))
+}
+
+// Descending sorts the results in descending order
+func (q *Query) Descending() *Query {
+	return q.With(OrderDirection(Descending))
+}
+
+// Limit returns the results from offset begin up to end
+func (q *Query) Limit(begin, end int) *Query {
+	return q.With(LimitBegin(begin), LimitEnd(end))
+}
+
+// Exact returns only exact matches
+func (q *Query) Exact() *Query {
+	return q.With(Exact(true))
+}
+
+// ByArtists restricts the results to those by the artists
+func (q *Query) ByArtists(ids ...ArtistID) *Query {
+	return q.With(ArtistIDs(ids...))
+}
+
+// StreamableOnly returns only streamable results
+func (q *Query) StreamableOnly() *Query {
+	return q.With(RestrictedToStreamable(true))
+}
+
+// AllowExplicit sets whether explicit results are returned
+func (q *Query) AllowExplicit(b bool) *Query {
+	return q.With(AllowExplicit(b))
+}
+
+// SpellCheck sets whether the server corrects the spelling of the query
+func (q *Query) SpellCheck(b bool) *Query {
+	return q.With(UseSpellCheck(b))
+}
+
+// AutoCorrect re-runs a search that finds nothing with the server's
+// "did you mean" suggestion
+func (q *Query) AutoCorrect() *Query {
+	return q.With(AutoCorrect(true))
+}
+
+// Text returns the text searched for
+func (q *Query) Text() string {
+	return q.text
+}
+
+// SearchTypes returns the result types searched for
+func (q *Query) SearchTypes() []SearchType {
+	return q.types
+}
+
+// Options returns the query's search options
+func (q *Query) Options() []searchOpt {
+	return q.opts
+}
+
+// Validate checks the query can be sent
+func (q *Query) Validate() error {
+	if q.text == "" {
+		return ErrEmptyQuery
+	}
+	if len(q.types) == 0 {
+		return ErrNoSearchTypes
+	}
+	for _, t := range q.types {
+		if !t.Valid() {
+			return fmt.Errorf("%w: %q", ErrUnknownSearchType, t)
+		}
+	}
+
+	s := Search{}
+	s.Option(q.opts...)
+
+	o, ordered := s.Order()
+	if ordered && !o.Valid() {
+		return fmt.Errorf("%w: %q", ErrBadOrder, o)
+	}
+	if d, ok := s.OrderDirection(); ok {
+		if !d.Valid() {
+			return fmt.Errorf("%w: %q", ErrUnknownDirection, d)
+		}
+		if !ordered {
+			return ErrDirectionWithoutOrder
+		}
+	}
+
+	begin, _ := s.LimitBegin()
+	end, hasEnd := s.LimitEnd()
+	if begin < 0 || (hasEnd && end < begin) {
+		return ErrBadLimits
+	}
+
+	if len(s.ArtistIDs()) != 0 {
+		for _, t := range q.types {
+			if t != SearchTracks && t != SearchTrackVersions && t != SearchAlbums {
+				return ErrArtistFilter
+			}
+		}
+	}
+
+	return nil
+}
+
+// Search validates and runs a simpleSearch for the query
+func (c *Client) Search(q *Query) (*SearchResult, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return c.SimpleSearch(q.text, q.types, q.opts...)
+}
+
+// SearchWithInfo validates and runs a simpleSearchWithInfo for the
+// query
+func (c *Client) SearchWithInfo(q *Query) (*SearchResultsWithInfo, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return c.SimpleSearchWithInfo(q.text, q.types, q.opts...)
+}
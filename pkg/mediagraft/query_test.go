@@ -0,0 +1,97 @@
+package mediagraft
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestQueryValidate(t *testing.T) {
+	for i, tc := range []struct {
+		q   *Query
+		err error
+	}{
+		{NewQuery("hendrix", SearchTracks), nil},
+		{NewQuery("hendrix", SearchTracks, SearchAlbums).OrderBy(OrderTitle).Descending().Limit(0, 10).ByArtists(42), nil},
+		{NewQuery("", SearchTracks), ErrEmptyQuery},
+		{NewQuery("hendrix"), ErrNoSearchTypes},
+		{NewQuery("hendrix", "songs"), ErrUnknownSearchType},
+		{NewQuery("hendrix", SearchTracks).OrderBy("size"), nil},
+		{NewQuery("hendrix", SearchTracks).OrderBy(""), ErrBadOrder},
+		{NewQuery("hendrix", SearchTracks).OrderBy("title desc"), ErrBadOrder},
+		{NewQuery("hendrix", SearchTracks).OrderBy("2title"), ErrBadOrder},
+		{NewQuery("hendrix", SearchTracks).OrderBy(OrderTitle).With(OrderDirection("up")), ErrUnknownDirection},
+		{NewQuery("hendrix", SearchTracks).Ascending(), ErrDirectionWithoutOrder},
+		{NewQuery("hendrix", SearchTracks).Limit(10, 5), ErrBadLimits},
+		{NewQuery("hendrix", SearchTracks).Limit(-1, 5), ErrBadLimits},
+		{NewQuery("hendrix", SearchTracks).Types(SearchArtists).ByArtists(42), ErrArtistFilter},
+	} {
+		if err := tc.q.Validate(); !errors.Is(err, tc.err) {
+			t.Errorf("%d. expected %v got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestSearchArgs(t *testing.T) {
+	q := NewQuery("purple haze", SearchTracks, SearchAlbums).
+		OrderBy(OrderPopularity).Descending().
+		Limit(10, 20).
+		Exact().
+		ByArtists(42, 43).
+		StreamableOnly().
+		AllowExplicit(false).
+		SpellCheck(true)
+
+	want := url.Values{
+		"query":                  {"purple haze"},
+		"type":                   {"tracks,albums"},
+		"order":                  {"popularity"},
+		"orderDirection":         {"desc"},
+		"limitBegin":             {"10"},
+		"limitEnd":               {"20"},
+		"exact":                  {"true"},
+		"artistIds":              {"42,43"},
+		"restrictedToStreamable": {"true"},
+		"allowExplicit":          {"false"},
+		"useSpellCheck":          {"true"},
+	}
+
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"simpleSearch": func(w http.ResponseWriter, r *http.Request) {
+			got := r.URL.Query()
+			for _, k := range []string{"apiKey", "appVersion", "format"} {
+				got.Del(k)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("sent %v expected %v", got, want)
+			}
+			w.Write([]byte(`{"tracks": [{"trackId": "1"}]}`))
+		},
+	})
+
+	res, err := c.Search(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Tracks) != 1 {
+		t.Errorf("unexpected results %+v", res)
+	}
+
+	if _, err := c.Search(NewQuery("purple haze")); err != ErrNoSearchTypes {
+		t.Errorf("expected an invalid query to be rejected got %v", err)
+	}
+}
+
+func TestSearchOptionRestore(t *testing.T) {
+	s := Search{}
+	previous := s.Option(Exact(true))
+	if v, ok := s.Exact(); !ok || !v {
+		t.Errorf("exact not set")
+	}
+	s.Option(previous)
+	if _, ok := s.Exact(); ok {
+		t.Errorf("exact not restored to unset")
+	}
+}
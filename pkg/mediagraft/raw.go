@@ -0,0 +1,63 @@
+package mediagraft
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RawResponse is an undecoded response, as returned by the Raw
+// variants of the API calls
+type RawResponse struct {
+	Method     string // The API method e.g. tracksInfo
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Timings    Timings
+
+	c *Client
+}
+
+// Timings records how long a call took
+type Timings struct {
+	Start  time.Time     // When the request was sent
+	Header time.Duration // Until the response headers were received
+	Total  time.Duration // Until the body was read
+}
+
+// CallRaw makes a call like Call and reads the whole response
+func (c *Client) CallRaw(httpmethod string, method string, vs *url.Values, body io.Reader) (*RawResponse, error) {
+	start := time.Now()
+	r, err := c.Call(httpmethod, method, vs, body)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	header := time.Since(start)
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RawResponse{
+		Method:     method,
+		StatusCode: r.StatusCode,
+		Header:     r.Header,
+		Body:       b,
+		Timings: Timings{
+			Start:  start,
+			Header: header,
+			Total:  time.Since(start),
+		},
+		c: c,
+	}, nil
+}
+
+// Decode leniently decodes the body into v, as the client that made
+// the call would
+func (r *RawResponse) Decode(v interface{}) error {
+	return r.c.decode(r.Method, bytes.NewReader(r.Body), v)
+}
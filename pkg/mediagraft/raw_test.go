@@ -0,0 +1,41 @@
+package mediagraft
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTracksInfoRaw(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": func(w http.ResponseWriter, r *http.Request) {
+			if ids := r.URL.Query().Get("ids"); ids != "1,2" {
+				t.Errorf("requested ids %q", ids)
+			}
+			w.Header().Set("X-Request-Id", "abc")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`[{"trackId": "1", "trackTitle": "Purple Haze"}]`))
+		},
+	})
+
+	r, err := c.TracksInfoRaw(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != "tracksInfo" || r.StatusCode != http.StatusAccepted || r.Header.Get("X-Request-Id") != "abc" {
+		t.Errorf("unexpected response %+v", r)
+	}
+	if string(r.Body) != `[{"trackId": "1", "trackTitle": "Purple Haze"}]` {
+		t.Errorf("unexpected body %s", r.Body)
+	}
+	if r.Timings.Start.IsZero() || r.Timings.Total < r.Timings.Header {
+		t.Errorf("unexpected timings %+v", r.Timings)
+	}
+
+	var ts []Track
+	if err := r.Decode(&ts); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 1 || ts[0].Id != 1 || ts[0].Title != "Purple Haze" {
+		t.Errorf("unexpected tracks %+v", ts)
+	}
+}
@@ -1,15 +1,12 @@
 package mediagraft
 
 import (
-	"encoding/json"
 	"io"
 	"net/url"
 	"strconv"
 	"strings"
 )
 
-var spaceReplacer = strings.NewReplacer(" ", "+")
-
 type SearchResult struct {
 	Artists       []Artist
 	Albums        []Album
@@ -22,9 +19,21 @@ type SearchResult struct {
 	SearchResultInfo
 }
 
+// Empty returns true if there are no results of any type
+func (r *SearchResult) Empty() bool {
+	return len(r.Artists) == 0 && len(r.Albums) == 0 && len(r.Tracks) == 0 &&
+		len(r.TrackVersions) == 0 && len(r.Genres) == 0 &&
+		len(r.RadioStations) == 0 && len(r.Playlists) == 0
+}
+
 type SearchResultInfo struct {
-	IsSearchFuzzy bool `json:"isSearchFuzze,string"`
+	IsSearchFuzzy bool `json:"isSearchFuzzy,string"`
 	DidYouMean    string
+
+	// Set when an AutoCorrect search was re-run with the DidYouMean
+	// suggestion, the results are those for CorrectedQuery
+	OriginalQuery  string `json:"-"`
+	CorrectedQuery string `json:"-"`
 }
 
 type SearchResultsWithInfo struct {
@@ -39,17 +48,100 @@ type SearchResultsWithInfo struct {
 	Status string
 }
 
+// SearchType is a type of result to search for
+type SearchType string
+
+const (
+	SearchTracks        SearchType = "tracks"
+	SearchTrackVersions SearchType = "trackVersions"
+	SearchAlbums        SearchType = "albums"
+	SearchArtists       SearchType = "artists"
+	SearchGenres        SearchType = "genres"
+	SearchRadioStations SearchType = "radioStations"
+	SearchPlaylists     SearchType = "playlists"
+)
+
+// SearchTypes lists all the types of result that can be searched for
+var SearchTypes = []SearchType{
+	SearchTracks,
+	SearchTrackVersions,
+	SearchAlbums,
+	SearchArtists,
+	SearchGenres,
+	SearchRadioStations,
+	SearchPlaylists,
+}
+
+// Valid returns true if t is one of SearchTypes
+func (t SearchType) Valid() bool {
+	for _, v := range SearchTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchOrder is the order search results are sorted in. The API
+// doesn't list the orders it supports, so any order made of letters and
+// digits is sent as is.
+type SearchOrder string
+
+// Common orders, these names are assumed rather than taken from the API
+// documentation
+const (
+	OrderRelevance   SearchOrder = "relevance"
+	OrderTitle       SearchOrder = "title"
+	OrderArtist      SearchOrder = "artist"
+	OrderPopularity  SearchOrder = "popularity"
+	OrderReleaseDate SearchOrder = "releaseDate"
+)
+
+// Valid returns true if o is well formed: a letter followed by letters
+// and digits, e.g. releaseDate. It doesn't check the server supports it.
+func (o SearchOrder) Valid() bool {
+	if o == "" {
+		return false
+	}
+	for i, r := range o {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Direction is the direction search results are sorted in
+type Direction string
+
+const (
+	Ascending  Direction = "asc"
+	Descending Direction = "desc"
+)
+
+// Valid returns true if d is Ascending or Descending
+func (d Direction) Valid() bool {
+	return d == Ascending || d == Descending
+}
+
 type searchOpt func(c *Search) searchOpt
+
+// Search holds the options for a search, options that haven't been
+// set aren't sent so the server's defaults apply
 type Search struct {
-	order                  *string
-	orderDirection         *string
+	order                  *SearchOrder
+	orderDirection         *Direction
 	limitBegin             *int
 	limitEnd               *int
 	exact                  *bool
-	artistIDs              []int
+	artistIDs              []ArtistID
 	restrictedToStreamable *bool
 	allowExplicit          *bool
 	useSpellCheck          *bool
+	autoCorrect            *bool // Not sent, handled by the client
 }
 
 func (s *Search) Option(opts ...searchOpt) (previous searchOpt) {
@@ -59,165 +151,272 @@ func (s *Search) Option(opts ...searchOpt) (previous searchOpt) {
 	return previous
 }
 
-// Verbosity sets the oauth client's log level
-func Order(o *string) searchOpt {
+// Order sets the order results are sorted in
+func Order(o SearchOrder) searchOpt {
+	return order(&o)
+}
+
+func order(o *SearchOrder) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.order
 		s.order = o
-		return Order(previous)
+		return order(previous)
 	}
 }
 
-func (s *Search) Order() *string {
-	return s.order
+// Order returns the order and whether it has been set
+func (s *Search) Order() (SearchOrder, bool) {
+	if s.order == nil {
+		return "", false
+	}
+	return *s.order, true
+}
+
+// OrderDirection sets the direction results are sorted in
+func OrderDirection(d Direction) searchOpt {
+	return orderDirection(&d)
 }
 
-// Verbosity sets the oauth client's log level
-func OrderDirection(o *string) searchOpt {
+func orderDirection(d *Direction) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.orderDirection
-		s.orderDirection = o
-		return OrderDirection(previous)
+		s.orderDirection = d
+		return orderDirection(previous)
 	}
 }
 
-func (s *Search) OrderDirection() *string {
-	return s.orderDirection
+// OrderDirection returns the direction and whether it has been set
+func (s *Search) OrderDirection() (Direction, bool) {
+	if s.orderDirection == nil {
+		return "", false
+	}
+	return *s.orderDirection, true
 }
 
-// Verbosity sets the oauth client's log level
-func LimitBegin(l *int) searchOpt {
+// LimitBegin sets the offset of the first result returned
+func LimitBegin(l int) searchOpt {
+	return limitBegin(&l)
+}
+
+func limitBegin(l *int) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.limitBegin
 		s.limitBegin = l
-		return LimitBegin(previous)
+		return limitBegin(previous)
 	}
 }
 
-func (s *Search) LimitBegin() *int {
-	return s.limitBegin
+// LimitBegin returns the offset of the first result and whether it has
+// been set
+func (s *Search) LimitBegin() (int, bool) {
+	return intOpt(s.limitBegin)
 }
 
-// Verbosity sets the oauth client's log level
-func LimitEnd(l *int) searchOpt {
+// LimitEnd sets the offset after the last result returned
+func LimitEnd(l int) searchOpt {
+	return limitEnd(&l)
+}
+
+func limitEnd(l *int) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.limitEnd
 		s.limitEnd = l
-		return LimitEnd(previous)
+		return limitEnd(previous)
 	}
 }
 
-func (s *Search) LimitEnd() *int {
-	return s.limitEnd
+// LimitEnd returns the offset after the last result and whether it has
+// been set
+func (s *Search) LimitEnd() (int, bool) {
+	return intOpt(s.limitEnd)
+}
+
+// Exact sets whether only exact matches are returned
+func Exact(b bool) searchOpt {
+	return exact(&b)
 }
 
-func Exact(b *bool) searchOpt {
+func exact(b *bool) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.exact
 		s.exact = b
-		return Exact(previous)
+		return exact(previous)
 	}
 }
 
-func (s *Search) Exact() *bool {
-	return s.exact
+func (s *Search) Exact() (bool, bool) {
+	return boolOpt(s.exact)
 }
 
-func ArtistIDs(l []int) searchOpt {
+// ArtistIDs restricts the results to those by the artists
+func ArtistIDs(ids ...ArtistID) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.artistIDs
-		s.artistIDs = l
-		return ArtistIDs(previous)
+		s.artistIDs = ids
+		return ArtistIDs(previous...)
 	}
 }
 
-func (s *Search) ArtistIDs() []int {
+func (s *Search) ArtistIDs() []ArtistID {
 	return s.artistIDs
 }
 
-func RestrictedToStreamable(r *bool) searchOpt {
+// RestrictedToStreamable sets whether only streamable results are
+// returned
+func RestrictedToStreamable(b bool) searchOpt {
+	return restrictedToStreamable(&b)
+}
+
+func restrictedToStreamable(b *bool) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.restrictedToStreamable
-		s.restrictedToStreamable = r
-		return RestrictedToStreamable(previous)
+		s.restrictedToStreamable = b
+		return restrictedToStreamable(previous)
 	}
 }
 
-func (s *Search) RestrictedToStreamable() *bool {
-	return s.restrictedToStreamable
+func (s *Search) RestrictedToStreamable() (bool, bool) {
+	return boolOpt(s.restrictedToStreamable)
+}
+
+// AllowExplicit sets whether explicit results are returned
+func AllowExplicit(b bool) searchOpt {
+	return allowExplicit(&b)
 }
 
-func AllowExplicit(e *bool) searchOpt {
+func allowExplicit(b *bool) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.allowExplicit
-		s.allowExplicit = e
-		return AllowExplicit(previous)
+		s.allowExplicit = b
+		return allowExplicit(previous)
 	}
 }
 
-func (s *Search) AllowExplicit() *bool {
-	return s.allowExplicit
+func (s *Search) AllowExplicit() (bool, bool) {
+	return boolOpt(s.allowExplicit)
+}
+
+// UseSpellCheck sets whether the server corrects the spelling of the
+// query
+func UseSpellCheck(b bool) searchOpt {
+	return useSpellCheck(&b)
 }
 
-func UseSpellCheck(c *bool) searchOpt {
+func useSpellCheck(b *bool) searchOpt {
 	return func(s *Search) searchOpt {
 		previous := s.useSpellCheck
-		s.useSpellCheck = c
-		return UseSpellCheck(previous)
+		s.useSpellCheck = b
+		return useSpellCheck(previous)
+	}
+}
+
+func (s *Search) UseSpellCheck() (bool, bool) {
+	return boolOpt(s.useSpellCheck)
+}
+
+// AutoCorrect sets whether a search that finds nothing is re-run with
+// the server's "did you mean" suggestion, if it made one
+func AutoCorrect(b bool) searchOpt {
+	return autoCorrect(&b)
+}
+
+func autoCorrect(b *bool) searchOpt {
+	return func(s *Search) searchOpt {
+		previous := s.autoCorrect
+		s.autoCorrect = b
+		return autoCorrect(previous)
+	}
+}
+
+func (s *Search) AutoCorrect() (bool, bool) {
+	return boolOpt(s.autoCorrect)
+}
+
+func intOpt(v *int) (int, bool) {
+	if v == nil {
+		return 0, false
 	}
+	return *v, true
 }
 
-func (s *Search) UseSpellCheck() *bool {
-	return s.useSpellCheck
+func boolOpt(v *bool) (bool, bool) {
+	if v == nil {
+		return false, false
+	}
+	return *v, true
 }
 
 func (s *Search) args() *url.Values {
 	a := &url.Values{}
 
-	if v := s.Order(); v != nil {
-		a.Add("order", *v)
+	if v, ok := s.Order(); ok {
+		a.Add("order", string(v))
+	}
+
+	if v, ok := s.OrderDirection(); ok {
+		a.Add("orderDirection", string(v))
+	}
+
+	if v, ok := s.LimitBegin(); ok {
+		a.Add("limitBegin", strconv.Itoa(v))
 	}
 
-	if v := s.OrderDirection(); v != nil {
-		a.Add("orderDirection", *v)
+	if v, ok := s.LimitEnd(); ok {
+		a.Add("limitEnd", strconv.Itoa(v))
 	}
 
-	if v := s.AllowExplicit(); v != nil {
-		a.Add("allowExplicit", strconv.FormatBool(*v))
+	if v, ok := s.AllowExplicit(); ok {
+		a.Add("allowExplicit", strconv.FormatBool(v))
 	}
 
 	if vs := s.ArtistIDs(); len(vs) != 0 {
 		var ss []string
 		for _, v := range vs {
-			ss = append(ss, strconv.Itoa(v))
+			ss = append(ss, strconv.Itoa(int(v)))
 		}
 		a.Add("artistIds", strings.Join(ss, ","))
 	}
 
-	if v := s.Exact(); v != nil {
-		a.Add("exact", strconv.FormatBool(*v))
+	if v, ok := s.Exact(); ok {
+		a.Add("exact", strconv.FormatBool(v))
 	}
 
-	if v := s.RestrictedToStreamable(); v != nil {
-		a.Add("restrictedToStreamable", strconv.FormatBool(*v))
+	if v, ok := s.RestrictedToStreamable(); ok {
+		a.Add("restrictedToStreamable", strconv.FormatBool(v))
 	}
 
-	if v := s.UseSpellCheck(); v != nil {
-		a.Add("useSpellCheck", strconv.FormatBool(*v))
+	if v, ok := s.UseSpellCheck(); ok {
+		a.Add("useSpellCheck", strconv.FormatBool(v))
 	}
 
 	return a
 }
 
-func (c *Client) SimpleSearch(q string, types []string, opts ...searchOpt) (*SearchResult, error) {
+func (c *Client) SimpleSearch(q string, types []SearchType, opts ...searchOpt) (*SearchResult, error) {
+	sr, err := c.simpleSearch(q, types, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if s := correction(q, &sr.SearchResultInfo, sr, opts); s != "" {
+		if sr, err = c.simpleSearch(s, types, opts...); err != nil {
+			return nil, err
+		}
+		sr.OriginalQuery, sr.CorrectedQuery = q, s
+	}
+
+	return sr, nil
+}
+
+func (c *Client) simpleSearch(q string, types []SearchType, opts ...searchOpt) (*SearchResult, error) {
 	r, err := c.doSearch("simpleSearch", q, types, opts...)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 
 	var sr SearchResult
-	dec := json.NewDecoder(r)
-	err = dec.Decode(&sr)
+	err = c.decode("simpleSearch", r, &sr)
 	if err != nil {
 		return nil, err
 	}
@@ -225,16 +424,33 @@ func (c *Client) SimpleSearch(q string, types []string, opts ...searchOpt) (*Sea
 	return &sr, nil
 }
 
-func (c *Client) SimpleSearchWithInfo(q string, types []string, opts ...searchOpt) (*SearchResultsWithInfo, error) {
+func (c *Client) SimpleSearchWithInfo(q string, types []SearchType, opts ...searchOpt) (*SearchResultsWithInfo, error) {
+	sr, err := c.simpleSearchWithInfo(q, types, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if s := correction(q, sr.info(), &sr.Data.SearchResults, opts); s != "" {
+		if sr, err = c.simpleSearchWithInfo(s, types, opts...); err != nil {
+			return nil, err
+		}
+		for _, info := range []*SearchResultInfo{&sr.Data.SearchResults.SearchResultInfo, &sr.Data.SearchResultsInfo.SearchResultInfo} {
+			info.OriginalQuery, info.CorrectedQuery = q, s
+		}
+	}
+
+	return sr, nil
+}
+
+func (c *Client) simpleSearchWithInfo(q string, types []SearchType, opts ...searchOpt) (*SearchResultsWithInfo, error) {
 	r, err := c.doSearch("simpleSearchWithInfo", q, types, opts...)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 
 	var sr SearchResultsWithInfo
-
-	dec := json.NewDecoder(r)
-	err = dec.Decode(&sr)
+	err = c.decode("simpleSearchWithInfo", r, &sr)
 	if err != nil {
 		return nil, err
 	}
@@ -242,15 +458,43 @@ func (c *Client) SimpleSearchWithInfo(q string, types []string, opts ...searchOp
 	return &sr, nil
 }
 
-func (c *Client) doSearch(method string, q string, types []string, opts ...searchOpt) (io.Reader, error) {
+// info returns the search info, which may be sent with the results
+// or the other info
+func (sr *SearchResultsWithInfo) info() *SearchResultInfo {
+	if info := &sr.Data.SearchResultsInfo.SearchResultInfo; info.DidYouMean != "" {
+		return info
+	}
+	return &sr.Data.SearchResults.SearchResultInfo
+}
+
+// correction returns the query to re-run a search for q with, or ""
+// if it shouldn't be
+func correction(q string, info *SearchResultInfo, r *SearchResult, opts []searchOpt) string {
 	s := Search{}
 	s.Option(opts...)
 
-	args := s.args()
-	args.Add("query", spaceReplacer.Replace(q))
-	args.Add("type", strings.Join(types, ","))
+	if ac, _ := s.AutoCorrect(); !ac || !r.Empty() {
+		return ""
+	}
+	if info.DidYouMean == "" || strings.EqualFold(info.DidYouMean, q) {
+		return ""
+	}
+	return info.DidYouMean
+}
+
+// SimpleSearchRaw returns the undecoded response to a simpleSearch
+func (c *Client) SimpleSearchRaw(q string, types []SearchType, opts ...searchOpt) (*RawResponse, error) {
+	return c.CallRaw("GET", "simpleSearch", searchArgs(q, types, opts...), nil)
+}
 
-	r, err := c.Call("GET", method, args, nil)
+// SimpleSearchWithInfoRaw returns the undecoded response to a
+// simpleSearchWithInfo
+func (c *Client) SimpleSearchWithInfoRaw(q string, types []SearchType, opts ...searchOpt) (*RawResponse, error) {
+	return c.CallRaw("GET", "simpleSearchWithInfo", searchArgs(q, types, opts...), nil)
+}
+
+func (c *Client) doSearch(method string, q string, types []SearchType, opts ...searchOpt) (io.ReadCloser, error) {
+	r, err := c.Call("GET", method, searchArgs(q, types, opts...), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -258,24 +502,49 @@ func (c *Client) doSearch(method string, q string, types []string, opts ...searc
 
 }
 
-func (c *Client) FindMatch(title string, artistname string, types []string) (*SearchResult, error) {
-	args := &url.Values{}
-	args.Add("title", spaceReplacer.Replace(title))
-	args.Add("artistName", spaceReplacer.Replace(artistname))
-	args.Add("type", strings.Join(types, ","))
+func searchArgs(q string, types []SearchType, opts ...searchOpt) *url.Values {
+	s := Search{}
+	s.Option(opts...)
 
-	r, err := c.Call("GET", "findMatch", args, nil)
+	args := s.args()
+	args.Add("query", q)
+	args.Add("type", joinTypes(types))
+	return args
+}
+
+func joinTypes(types []SearchType) string {
+	ss := make([]string, len(types))
+	for i, t := range types {
+		ss[i] = string(t)
+	}
+	return strings.Join(ss, ",")
+}
+
+func (c *Client) FindMatch(title string, artistname string, types []SearchType) (*SearchResult, error) {
+	r, err := c.Call("GET", "findMatch", findMatchArgs(title, artistname, types), nil)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
 
 	var sr SearchResult
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&sr)
+	err = c.decode("findMatch", r.Body, &sr)
 	if err != nil {
 		return nil, err
 	}
 
 	return &sr, nil
 }
+
+// FindMatchRaw returns the undecoded response to a findMatch
+func (c *Client) FindMatchRaw(title string, artistname string, types []SearchType) (*RawResponse, error) {
+	return c.CallRaw("GET", "findMatch", findMatchArgs(title, artistname, types), nil)
+}
+
+func findMatchArgs(title string, artistname string, types []SearchType) *url.Values {
+	args := &url.Values{}
+	args.Add("title", title)
+	args.Add("artistName", artistname)
+	args.Add("type", joinTypes(types))
+	return args
+}
@@ -0,0 +1,123 @@
+package mediagraft
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/we7/go-mediagraft/pkg/mediagraft/oauth"
+)
+
+// didYouMeanHandler finds "purple haze", suggesting it for "purpel haze"
+func didYouMeanHandler(calls *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		*calls = append(*calls, q)
+		if q == "purple haze" {
+			w.Write([]byte(`{"tracks": [{"trackId": "1"}], "isSearchFuzzy": "false"}`))
+			return
+		}
+		w.Write([]byte(`{"tracks": [], "isSearchFuzzy": "true", "didYouMean": "purple haze"}`))
+	}
+}
+
+func TestSearchAutoCorrect(t *testing.T) {
+	var calls []string
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"simpleSearch": didYouMeanHandler(&calls),
+	})
+
+	sr, err := c.SimpleSearch("purpel haze", []SearchType{SearchTracks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sr.Empty() || !sr.IsSearchFuzzy || sr.DidYouMean != "purple haze" || sr.CorrectedQuery != "" {
+		t.Errorf("expected uncorrected results got %+v", sr)
+	}
+
+	calls = nil
+	sr, err = c.Search(NewQuery("purpel haze", SearchTracks).AutoCorrect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sr.Tracks) != 1 || sr.IsSearchFuzzy {
+		t.Errorf("expected corrected results got %+v", sr)
+	}
+	if sr.OriginalQuery != "purpel haze" || sr.CorrectedQuery != "purple haze" {
+		t.Errorf("unexpected queries %q %q", sr.OriginalQuery, sr.CorrectedQuery)
+	}
+	if strings.Join(calls, ",") != "purpel haze,purple haze" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestSearchWithInfoAutoCorrect(t *testing.T) {
+	var calls []string
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"simpleSearchWithInfo": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query().Get("query")
+			calls = append(calls, q)
+			if q == "purple haze" {
+				w.Write([]byte(`{"data": {"searchResults": {"tracks": [{"trackId": "1"}]}, "searchResultsInfo": {"totalNumberOfResults": "1"}}}`))
+				return
+			}
+			w.Write([]byte(`{"data": {"searchResults": {}, "searchResultsInfo": {"totalNumberOfResults": "0", "isSearchFuzzy": "true", "didYouMean": "purple haze"}}}`))
+		},
+	})
+
+	sr, err := c.SimpleSearchWithInfo("purpel haze", []SearchType{SearchTracks}, AutoCorrect(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := sr.Data.SearchResultsInfo
+	if len(sr.Data.SearchResults.Tracks) != 1 || info.TotalNumberOfResults != 1 {
+		t.Errorf("expected corrected results got %+v", sr)
+	}
+	if info.OriginalQuery != "purpel haze" || info.CorrectedQuery != "purple haze" {
+		t.Errorf("unexpected queries %q %q", info.OriginalQuery, info.CorrectedQuery)
+	}
+	if len(calls) != 2 {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestSearchQuerySigning(t *testing.T) {
+	creds := oauth.DefaultCredentials()
+	creds.TokenType = "MAC"
+	creds.AccessToken = "tok"
+	creds.Secret = "secret"
+
+	tests := []struct {
+		args *url.Values
+		enc  oauth.QueryEncoding
+		want []string
+	}{
+		{searchArgs("jimi hendrix purple haze", []SearchType{SearchTracks}), oauth.FormEncoding,
+			[]string{"\nquery=jimi+hendrix+purple+haze\n"}},
+		{searchArgs("jimi hendrix purple haze", []SearchType{SearchTracks}), oauth.RFC3986Encoding,
+			[]string{"\nquery=jimi%20hendrix%20purple%20haze\n"}},
+		{findMatchArgs("purple haze", "jimi hendrix", []SearchType{SearchTracks}), oauth.FormEncoding,
+			[]string{"\nartistName=jimi+hendrix\n", "\ntitle=purple+haze\n"}},
+	}
+	for i, test := range tests {
+		r, _ := http.NewRequest("GET", "http://api.we7.com/api/0.1/simpleSearch?"+test.args.Encode(), nil)
+		for _, k := range []string{"query", "title", "artistName"} {
+			if v := r.URL.Query().Get(k); strings.Contains(v, "+") {
+				t.Errorf("%d. expected %s to be sent with spaces got %q", i, k, v)
+			}
+		}
+
+		creds.Encoding = test.enc
+		sig := creds.Sign(r, time.Unix(1312471030, 0), "n")
+		for _, want := range test.want {
+			if !strings.Contains(sig.BaseString, want) {
+				t.Errorf("%d. expected %q in the base string %q", i, want, sig.BaseString)
+			}
+		}
+		if strings.Contains(sig.BaseString, "%2B") {
+			t.Errorf("%d. unexpected escaped + in the base string %q", i, sig.BaseString)
+		}
+	}
+}
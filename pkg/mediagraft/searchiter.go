@@ -0,0 +1,229 @@
+package mediagraft
+
+import "fmt"
+
+// DefaultSearchPageSize is the number of results fetched per page by
+// a SearchIterator
+const DefaultSearchPageSize = 20
+
+// SearchIterator walks the pages of a simpleSearchWithInfo for a single
+// result type, fetching each page when it's needed:
+//
+//	it := c.SearchIterator("jimi hendrix", SearchTracks)
+//	it.Option(PageSize(50), Prefetch(true))
+//	for it.Next() {
+//		for _, t := range it.Page().Tracks {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Pages are requested with LimitBegin as the offset of their first
+// result and LimitEnd as the offset after their last. The iteration
+// ends when the server reports the results are exhausted, the total
+// number of results has been reached or a page is empty. With the
+// AutoCorrect option only the first page may be corrected, the rest
+// are fetched for the corrected query.
+type SearchIterator struct {
+	c        *Client
+	q        string
+	typ      SearchType
+	opts     []searchOpt
+	pageSize int
+	prefetch bool
+
+	started   bool            // Set once the first page is fetched
+	begin     int             // The offset of the next page
+	pending   chan searchPage // The next page when it's being prefetched
+	page      *SearchResult
+	offset    int
+	total     int
+	exhausted bool
+	err       error
+}
+
+type searchPage struct {
+	res *SearchResultsWithInfo
+	err error
+}
+
+type searchIterOpt func(it *SearchIterator) searchIterOpt
+
+// SearchIterator returns an iterator over the pages of results of type
+// typ e.g. SearchTracks for q. The search starts at the LimitBegin option
+// if it's given, LimitEnd is ignored.
+func (c *Client) SearchIterator(q string, typ SearchType, opts ...searchOpt) *SearchIterator {
+	s := Search{}
+	s.Option(opts...)
+
+	it := &SearchIterator{
+		c:        c,
+		q:        q,
+		typ:      typ,
+		opts:     opts,
+		pageSize: DefaultSearchPageSize,
+	}
+	if b, ok := s.LimitBegin(); ok {
+		it.begin = b
+	}
+	return it
+}
+
+// Option sets the options specified, they should be set before the
+// first call to Next.
+// It returns an option to restore the last arg's previous value.
+func (it *SearchIterator) Option(opts ...searchIterOpt) (previous searchIterOpt) {
+	for _, opt := range opts {
+		previous = opt(it)
+	}
+	return previous
+}
+
+// PageSize sets the number of results fetched per page
+func PageSize(n int) searchIterOpt {
+	return func(it *SearchIterator) searchIterOpt {
+		previous := it.pageSize
+		it.pageSize = n
+		return PageSize(previous)
+	}
+}
+
+// Prefetch sets whether the next page is fetched in the background
+// while the current one is being used
+func Prefetch(p bool) searchIterOpt {
+	return func(it *SearchIterator) searchIterOpt {
+		previous := it.prefetch
+		it.prefetch = p
+		return Prefetch(previous)
+	}
+}
+
+// Next fetches the next page, returning false when there are no more
+// results or an error occurred
+func (it *SearchIterator) Next() bool {
+	if it.err != nil || it.exhausted {
+		return false
+	}
+	if it.pageSize <= 0 {
+		it.pageSize = DefaultSearchPageSize
+	}
+	if _, err := resultCount(&SearchResult{}, it.typ); err != nil {
+		it.err = err
+		return false
+	}
+
+	var p searchPage
+	if it.pending != nil {
+		p = <-it.pending
+		it.pending = nil
+	} else {
+		p = it.c.fetchSearchPage(it.q, it.typ, it.pageOpts(it.begin))
+	}
+	if p.err != nil {
+		it.err = p.err
+		return false
+	}
+
+	info := p.res.Data.SearchResultsInfo
+	n, _ := resultCount(&p.res.Data.SearchResults, it.typ)
+
+	if !it.started {
+		it.started = true
+		if info.CorrectedQuery != "" {
+			it.q = info.CorrectedQuery
+		}
+	}
+
+	it.page = &p.res.Data.SearchResults
+	it.offset = it.begin
+	it.total = info.TotalNumberOfResults
+	// The server may send fewer results than asked for
+	it.begin += n
+	it.exhausted = info.ResultsExhausted || (it.total > 0 && it.begin >= it.total)
+	if n == 0 {
+		it.exhausted = true
+		return false
+	}
+
+	if it.prefetch && !it.exhausted {
+		it.pending = make(chan searchPage, 1)
+		// Everything the request needs is passed in, so the goroutine
+		// doesn't read the iterator while the caller uses it
+		go func(c *Client, pending chan searchPage, q string, typ SearchType, opts []searchOpt) {
+			pending <- c.fetchSearchPage(q, typ, opts)
+		}(it.c, it.pending, it.q, it.typ, it.pageOpts(it.begin))
+	}
+	return true
+}
+
+// pageOpts returns a new slice of the options to fetch the page
+// starting at begin
+func (it *SearchIterator) pageOpts(begin int) []searchOpt {
+	end := begin + it.pageSize
+	opts := append(it.opts[:len(it.opts):len(it.opts)], LimitBegin(begin), LimitEnd(end))
+	if it.started {
+		// Only the first page is corrected
+		opts = append(opts, AutoCorrect(false))
+	}
+	return opts
+}
+
+// fetchSearchPage fetches a page of results of type typ for q
+func (c *Client) fetchSearchPage(q string, typ SearchType, opts []searchOpt) searchPage {
+	res, err := c.SimpleSearchWithInfo(q, []SearchType{typ}, opts...)
+	return searchPage{res, err}
+}
+
+// Query returns the query the results are for, this is the server's
+// suggestion if the first page was auto corrected
+func (it *SearchIterator) Query() string {
+	return it.q
+}
+
+// Page returns the results fetched by the last call to Next
+func (it *SearchIterator) Page() *SearchResult {
+	return it.page
+}
+
+// Offset returns the offset of the first result of the current page
+func (it *SearchIterator) Offset() int {
+	return it.offset
+}
+
+// Total returns the total number of results reported by the server
+func (it *SearchIterator) Total() int {
+	return it.total
+}
+
+// Exhausted returns true once the last page has been fetched
+func (it *SearchIterator) Exhausted() bool {
+	return it.exhausted
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *SearchIterator) Err() error {
+	return it.err
+}
+
+// resultCount returns the number of results of type typ in r
+func resultCount(r *SearchResult, typ SearchType) (int, error) {
+	switch typ {
+	case SearchTracks:
+		return len(r.Tracks), nil
+	case SearchTrackVersions:
+		return len(r.TrackVersions), nil
+	case SearchAlbums:
+		return len(r.Albums), nil
+	case SearchArtists:
+		return len(r.Artists), nil
+	case SearchGenres:
+		return len(r.Genres), nil
+	case SearchRadioStations:
+		return len(r.RadioStations), nil
+	case SearchPlaylists:
+		return len(r.Playlists), nil
+	}
+	return 0, fmt.Errorf("unknown search result type %q", typ)
+}
@@ -0,0 +1,183 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// searchHandler serves total tracks from simpleSearchWithInfo, paged
+// by limitBegin and limitEnd with at most maxPage tracks in a page if
+// it's not 0
+func searchHandler(t *testing.T, total, maxPage int, calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		q := r.URL.Query()
+		if q.Get("type") != "tracks" || q.Get("query") != "hendrix" || q.Get("order") != "title" {
+			t.Errorf("unexpected query %v", q)
+		}
+		begin, _ := strconv.Atoi(q.Get("limitBegin"))
+		end, _ := strconv.Atoi(q.Get("limitEnd"))
+		if end > total {
+			end = total
+		}
+		if maxPage > 0 && end-begin > maxPage {
+			end = begin + maxPage
+		}
+
+		var ts []map[string]string
+		for i := begin; i < end; i++ {
+			ts = append(ts, map[string]string{"trackId": strconv.Itoa(i + 1)})
+		}
+		res := map[string]interface{}{
+			"status": "ok",
+			"data": map[string]interface{}{
+				"searchResults": map[string]interface{}{"tracks": ts},
+				"searchResultsInfo": map[string]string{
+					"totalNumberOfResults": strconv.Itoa(total),
+					"resultExausted":       strconv.FormatBool(end >= total),
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+func TestSearchIterator(t *testing.T) {
+	for i, prefetch := range []bool{false, true} {
+		var calls int32
+		c := newTestClient(t, map[string]http.HandlerFunc{
+			"simpleSearchWithInfo": searchHandler(t, 45, 0, &calls),
+		})
+
+		it := c.SearchIterator("hendrix", SearchTracks, Order(OrderTitle))
+		it.Option(PageSize(20), Prefetch(prefetch))
+
+		var sizes []int
+		next := TrackID(1)
+		for it.Next() {
+			sizes = append(sizes, len(it.Page().Tracks))
+			if it.Offset() != int(next-1) {
+				t.Errorf("%d. page offset %d expected %d", i, it.Offset(), next-1)
+			}
+			for _, tr := range it.Page().Tracks {
+				if tr.Id != next {
+					t.Errorf("%d. got track %d expected %d", i, tr.Id, next)
+				}
+				next++
+			}
+			if it.Total() != 45 {
+				t.Errorf("%d. total %d", i, it.Total())
+			}
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("%d. %v", i, err)
+		}
+		if len(sizes) != 3 || sizes[0] != 20 || sizes[1] != 20 || sizes[2] != 5 {
+			t.Errorf("%d. unexpected page sizes %v", i, sizes)
+		}
+		if !it.Exhausted() || it.Next() {
+			t.Errorf("%d. expected the iterator to be exhausted", i)
+		}
+		if calls != 3 {
+			t.Errorf("%d. expected 3 calls got %d", i, calls)
+		}
+	}
+}
+
+func TestSearchIteratorStart(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"simpleSearchWithInfo": searchHandler(t, 10, 0, &calls),
+	})
+
+	it := c.SearchIterator("hendrix", SearchTracks, Order(OrderTitle), LimitBegin(6))
+	it.Option(PageSize(3))
+
+	var ids []TrackID
+	for it.Next() {
+		for _, tr := range it.Page().Tracks {
+			ids = append(ids, tr.Id)
+		}
+	}
+	if len(ids) != 4 || ids[0] != 7 || ids[3] != 10 {
+		t.Errorf("unexpected tracks %v", ids)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls got %d", calls)
+	}
+}
+
+func TestSearchIteratorShortPages(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"simpleSearchWithInfo": searchHandler(t, 20, 7, &calls),
+	})
+
+	it := c.SearchIterator("hendrix", SearchTracks, Order(OrderTitle))
+	it.Option(PageSize(10))
+
+	var sizes []int
+	next := TrackID(1)
+	for it.Next() {
+		sizes = append(sizes, len(it.Page().Tracks))
+		for _, tr := range it.Page().Tracks {
+			if tr.Id != next {
+				t.Errorf("got track %d expected %d", tr.Id, next)
+			}
+			next++
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sizes, []int{7, 7, 6}) {
+		t.Errorf("expected short pages not to end the iteration got page sizes %v", sizes)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls got %d", calls)
+	}
+}
+
+func TestSearchIteratorAutoCorrect(t *testing.T) {
+	var calls int32
+	var queries []string
+	pages := searchHandler(t, 5, 0, &calls)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"simpleSearchWithInfo": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			queries = append(queries, q.Get("query")+" "+q.Get("limitBegin"))
+			if q.Get("query") == "hendirx" {
+				w.Write([]byte(`{"data": {"searchResults": {}, "searchResultsInfo": {"totalNumberOfResults": "0", "didYouMean": "hendrix"}}}`))
+				return
+			}
+			pages(w, r)
+		},
+	})
+
+	it := c.SearchIterator("hendirx", SearchTracks, Order(OrderTitle), AutoCorrect(true))
+	it.Option(PageSize(2))
+
+	var ids []TrackID
+	for it.Next() {
+		for _, tr := range it.Page().Tracks {
+			ids = append(ids, tr.Id)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []TrackID{1, 2, 3, 4, 5}) {
+		t.Errorf("unexpected tracks %v", ids)
+	}
+	if it.Query() != "hendrix" {
+		t.Errorf("expected the corrected query got %q", it.Query())
+	}
+	want := []string{"hendirx 0", "hendrix 0", "hendrix 2", "hendrix 4"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("expected only the first page to be corrected, calls %v", queries)
+	}
+}
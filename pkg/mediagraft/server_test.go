@@ -0,0 +1,23 @@
+package mediagraft
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/we7/go-mediagraft/pkg/mediagraft/internal/fakeserver"
+)
+
+// newTestClient returns a client talking to a fake server, handlers
+// are keyed by API method e.g. "tracksInfo" or "radio/getStation". It
+// mirrors mediagrafttest.NewClient, which can't be imported here.
+func newTestClient(t *testing.T, handlers map[string]http.HandlerFunc) *Client {
+	srv := httptest.NewServer(fakeserver.Handler("0.1", handlers))
+	t.Cleanup(srv.Close)
+
+	c := New(Host(strings.TrimPrefix(srv.URL, "http://")))
+	c.Proto = "http"
+	c.ApiVersion = "0.1"
+	return c
+}
@@ -0,0 +1,106 @@
+package mediagraft
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// StationList is a page of stations from one of the browse calls
+type StationList struct {
+	Stations []Station `json:"stations"`
+	Total    int       `json:"totalNumberOfResults,string"` // The stations in every page
+	Begin    int       `json:"-"`                           // The offset of the first station
+	End      int       `json:"-"`                           // The offset the page was asked to end at
+
+	browse func(begin, end int) (*StationList, error)
+}
+
+// More returns true if there are stations after this page
+func (l *StationList) More() bool {
+	return len(l.Stations) != 0 && l.Begin+len(l.Stations) < l.Total
+}
+
+// NextPage fetches the stations after this page, asking for as many
+// as this page did. It returns nil when there are no more:
+//
+//	l, err := c.PopularStations(0, 50)
+//	for ; l != nil && err == nil; l, err = l.NextPage() {
+//		for _, s := range l.Stations {
+//			...
+//		}
+//	}
+//	if err != nil {
+//		...
+//	}
+func (l *StationList) NextPage() (*StationList, error) {
+	if !l.More() || l.browse == nil {
+		return nil, nil
+	}
+	// The server may send fewer stations than asked for
+	begin := l.Begin + len(l.Stations)
+	return l.browse(begin, begin+l.End-l.Begin)
+}
+
+// The browse calls return the stations from offset begin up to but not
+// including end, the server may send fewer. They return ErrBadLimits if
+// begin is negative or end is before it.
+//
+// The API doesn't document the browse calls, their names, parameters
+// and the limitBegin and limitEnd paging borrowed from search are
+// assumptions.
+
+// PromotedStations returns the featured stations, as shown on the home
+// screen
+func (c *Client) PromotedStations(begin, end int) (*StationList, error) {
+	return c.browseStations("radio/promotedStations", nil, begin, end)
+}
+
+// PopularStations returns the stations in order of popularity
+func (c *Client) PopularStations(begin, end int) (*StationList, error) {
+	return c.browseStations("radio/popularStations", nil, begin, end)
+}
+
+// StationsByTag returns the stations with the tag
+func (c *Client) StationsByTag(tag string, begin, end int) (*StationList, error) {
+	return c.browseStations("radio/stationsByTag", url.Values{"tag": {tag}}, begin, end)
+}
+
+// StationsByGenre returns the stations of the genre
+func (c *Client) StationsByGenre(id GenreID, begin, end int) (*StationList, error) {
+	return c.browseStations("radio/stationsByGenre", url.Values{"genreId": {strconv.Itoa(int(id))}}, begin, end)
+}
+
+func (c *Client) browseStations(method string, params url.Values, begin, end int) (*StationList, error) {
+	if begin < 0 || end < begin {
+		return nil, ErrBadLimits
+	}
+
+	args := &url.Values{}
+	for k, v := range params {
+		(*args)[k] = v
+	}
+	args.Set("limitBegin", strconv.Itoa(begin))
+	args.Set("limitEnd", strconv.Itoa(end))
+
+	r, err := c.Call("GET", method, args, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", method, r.Status)
+	}
+
+	l := StationList{Begin: begin, End: end}
+	err = c.decode(method, r.Body, &l)
+	if err != nil {
+		return nil, err
+	}
+	l.browse = func(begin, end int) (*StationList, error) {
+		return c.browseStations(method, params, begin, end)
+	}
+
+	return &l, nil
+}
@@ -0,0 +1,175 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPromotedStations(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"radio/promotedStations": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("limitBegin") != "0" || q.Get("limitEnd") != "2" {
+				t.Errorf("expected limits 0 and 2 got %q and %q", q.Get("limitBegin"), q.Get("limitEnd"))
+			}
+			http.ServeFile(w, r, "testdata/promotedStations.json")
+		},
+	})
+
+	l, err := c.PromotedStations(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Total != 3 || l.Begin != 0 || len(l.Stations) != 2 || !l.More() {
+		t.Fatalf("unexpected list %+v", l)
+	}
+
+	s := l.Stations[0]
+	want := Station{
+		ID:            "a42",
+		Name:          "Jimi Hendrix Radio",
+		Description:   "Psychedelic guitar and the artists he inspired",
+		Subtitle:      "Artist radio",
+		Badge:         "NEW",
+		Promoted:      true,
+		Popularity:    0.93,
+		Searchable:    true,
+		ExplicitCount: 2,
+		TrackCount:    250,
+		Tags:          []string{"rock", "guitar"},
+		Artists:       []string{"Jimi Hendrix", "Cream"},
+		Images:        Images{"original": "http://img.example.com/a42.jpg"},
+		CategorizedImages: map[string]Images{
+			"background": {"1280x720": "http://img.example.com/a42-bg.jpg"},
+			"tile":       {"200x200": "http://img.example.com/a42-tile.jpg"},
+		},
+		Moods: StationMood{Energy: 0.7, Tempo: 0.6, Variety: 0.5, Popularity: 0.8},
+		Seeds: []StationSeed{{ID: 42, Name: "Jimi Hendrix", Type: SeedArtist, Full: true}},
+		URL:   "http://www.example.com/radio/a42",
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("expected %+v\ngot %+v", want, s)
+	}
+
+	// Drifted values in the second station
+	s = l.Stations[1]
+	if !s.Promoted || s.Popularity != 0.81 || !reflect.DeepEqual(s.Tags, []string{"rock"}) {
+		t.Errorf("unexpected station %+v", s)
+	}
+	if len(s.Seeds) != 1 || s.Seeds[0].Type != SeedGenre || s.Seeds[0].ID != 7 {
+		t.Errorf("unexpected seeds %+v", s.Seeds)
+	}
+}
+
+func TestBrowseStations(t *testing.T) {
+	var got []string
+	handler := func(method string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			got = append(got, method+" "+q.Get("tag")+q.Get("genreId")+" "+q.Get("limitBegin")+"-"+q.Get("limitEnd"))
+			w.Write([]byte(`{"totalNumberOfResults": "12", "stations": [{"id": "a1"}, {"id": "a2"}]}`))
+		}
+	}
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"radio/popularStations": handler("radio/popularStations"),
+		"radio/stationsByTag":   handler("radio/stationsByTag"),
+		"radio/stationsByGenre": handler("radio/stationsByGenre"),
+	})
+
+	tests := []struct {
+		browse func() (*StationList, error)
+		call   string
+		more   bool
+	}{
+		{func() (*StationList, error) { return c.PopularStations(0, 2) }, "radio/popularStations  0-2", true},
+		{func() (*StationList, error) { return c.StationsByTag("chill out", 4, 6) }, "radio/stationsByTag chill out 4-6", true},
+		{func() (*StationList, error) { return c.StationsByGenre(7, 10, 12) }, "radio/stationsByGenre 7 10-12", false},
+	}
+	for i, test := range tests {
+		l, err := test.browse()
+		if err != nil {
+			t.Errorf("%d. %v", i, err)
+			continue
+		}
+		if got[i] != test.call {
+			t.Errorf("%d. expected call %q got %q", i, test.call, got[i])
+		}
+		if len(l.Stations) != 2 || l.Stations[1].ID != "a2" || l.Total != 12 {
+			t.Errorf("%d. unexpected list %+v", i, l)
+		}
+		if l.More() != test.more {
+			t.Errorf("%d. expected More %v", i, test.more)
+		}
+	}
+}
+
+func TestBrowseStationsErrors(t *testing.T) {
+	var calls int
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"radio/popularStations": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		},
+	})
+
+	for i, limits := range [][2]int{{-1, 2}, {5, 4}} {
+		if _, err := c.PopularStations(limits[0], limits[1]); err != ErrBadLimits {
+			t.Errorf("%d. expected ErrBadLimits for %v got %v", i, limits, err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("expected bad limits not to be sent, got %d calls", calls)
+	}
+
+	if l, err := c.PopularStations(0, 2); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected an error for a 503 got %+v, %v", l, err)
+	}
+}
+
+func TestStationListNextPage(t *testing.T) {
+	const total, maxPage = 7, 2
+	var calls []string
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"radio/stationsByTag": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			calls = append(calls, q.Get("tag")+" "+q.Get("limitBegin")+"-"+q.Get("limitEnd"))
+			begin, _ := strconv.Atoi(q.Get("limitBegin"))
+			end, _ := strconv.Atoi(q.Get("limitEnd"))
+			if end > total {
+				end = total
+			}
+			if end-begin > maxPage {
+				end = begin + maxPage
+			}
+			ss := []map[string]string{}
+			for i := begin; i < end; i++ {
+				ss = append(ss, map[string]string{"id": "a" + strconv.Itoa(i+1)})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"totalNumberOfResults": strconv.Itoa(total),
+				"stations":             ss,
+			})
+		},
+	})
+
+	var ids []StationIdent
+	l, err := c.StationsByTag("rock", 0, 3)
+	for ; l != nil && err == nil; l, err = l.NextPage() {
+		for _, s := range l.Stations {
+			ids = append(ids, s.ID)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []StationIdent{"a1", "a2", "a3", "a4", "a5", "a6", "a7"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected stations %v got %v", want, ids)
+	}
+	if want := []string{"rock 0-3", "rock 2-5", "rock 4-7", "rock 6-9"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected calls %v got %v", want, calls)
+	}
+}
@@ -0,0 +1,227 @@
+package mediagraft
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SeedType is the kind of entity a station is seeded from
+type SeedType string
+
+const (
+	SeedArtist   SeedType = "artist"
+	SeedTrack    SeedType = "track"
+	SeedGenre    SeedType = "genre"
+	SeedPlaylist SeedType = "playlist"
+)
+
+// seedPrefixes are the letters that start each seed in a station ident
+var seedPrefixes = map[SeedType]byte{
+	SeedArtist:   'a',
+	SeedTrack:    't',
+	SeedGenre:    'g',
+	SeedPlaylist: 'p',
+}
+
+// stationIdentSep separates the seeds of a multi-seed station ident
+const stationIdentSep = "_"
+
+var (
+	ErrNoSeeds         = errors.New("A station needs at least one seed")
+	ErrUnknownSeedType = errors.New("Unknown station seed type")
+	ErrBadStationIdent = errors.New("Malformed station ident")
+)
+
+// ArtistSeed returns a seed for a station of music like the artist's
+func ArtistSeed(id ArtistID) StationSeed {
+	return StationSeed{ID: int(id), Type: SeedArtist}
+}
+
+// TrackSeed returns a seed for a station of music like the track
+func TrackSeed(id TrackID) StationSeed {
+	return StationSeed{ID: int(id), Type: SeedTrack}
+}
+
+// GenreSeed returns a seed for a station of the genre
+func GenreSeed(id GenreID) StationSeed {
+	return StationSeed{ID: int(id), Type: SeedGenre}
+}
+
+// PlaylistSeed returns a seed for a station of music like the
+// playlist's
+func PlaylistSeed(id PlaylistID) StationSeed {
+	return StationSeed{ID: int(id), Type: SeedPlaylist}
+}
+
+// The station ident helpers panic if the id isn't positive, use the
+// seed's Ident method to get an error instead.
+
+// ArtistStation returns the ident of the artist's station
+func ArtistStation(id ArtistID) StationIdent {
+	return mustIdent(ArtistSeed(id))
+}
+
+// TrackStation returns the ident of the track's station
+func TrackStation(id TrackID) StationIdent {
+	return mustIdent(TrackSeed(id))
+}
+
+// GenreStation returns the ident of the genre's station
+func GenreStation(id GenreID) StationIdent {
+	return mustIdent(GenreSeed(id))
+}
+
+// PlaylistStation returns the ident of the playlist's station
+func PlaylistStation(id PlaylistID) StationIdent {
+	return mustIdent(PlaylistSeed(id))
+}
+
+// Ident returns the ident of the station seeded from just s, it fails
+// with ErrUnknownSeedType if s has no type or one without an ident and
+// ErrBadStationIdent if its id isn't positive
+func (s StationSeed) Ident() (StationIdent, error) {
+	return SeedsIdent(s)
+}
+
+// mustIdent returns the ident of a seed made by one of the seed
+// constructors, panicking if its id isn't positive
+func mustIdent(s StationSeed) StationIdent {
+	ident, err := s.Ident()
+	if err != nil {
+		panic(err)
+	}
+	return ident
+}
+
+// SeedsIdent returns the ident of the station seeded from seeds, in
+// the form a1_t2 for the station of artist 1 and track 2. Seed ids
+// must be positive.
+func SeedsIdent(seeds ...StationSeed) (StationIdent, error) {
+	if len(seeds) == 0 {
+		return "", ErrNoSeeds
+	}
+	parts := make([]string, len(seeds))
+	for i, s := range seeds {
+		prefix, ok := seedPrefixes[s.Type]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", ErrUnknownSeedType, s.Type)
+		}
+		if s.ID <= 0 {
+			return "", fmt.Errorf("%w: %s id %d", ErrBadStationIdent, s.Type, s.ID)
+		}
+		parts[i] = string(prefix) + strconv.Itoa(s.ID)
+	}
+	return StationIdent(strings.Join(parts, stationIdentSep)), nil
+}
+
+// Seeds parses the ident back into the seeds it's made of. Only the
+// seeds' IDs and Types are set.
+func (ident StationIdent) Seeds() ([]StationSeed, error) {
+	if ident == "" {
+		return nil, fmt.Errorf("%w: empty", ErrBadStationIdent)
+	}
+
+	var seeds []StationSeed
+	for _, part := range strings.Split(string(ident), stationIdentSep) {
+		s, ok := parseSeed(part)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrBadStationIdent, ident)
+		}
+		seeds = append(seeds, s)
+	}
+	return seeds, nil
+}
+
+// parseSeed parses one seed of an ident, the id must be in the form
+// SeedsIdent writes it: a positive number without leading zeros
+func parseSeed(part string) (StationSeed, bool) {
+	if len(part) < 2 {
+		return StationSeed{}, false
+	}
+	digits := part[1:]
+	if digits[0] == '0' {
+		return StationSeed{}, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return StationSeed{}, false
+		}
+	}
+	id, err := strconv.Atoi(digits)
+	if err != nil {
+		return StationSeed{}, false
+	}
+	for typ, prefix := range seedPrefixes {
+		if part[0] == prefix {
+			return StationSeed{ID: id, Type: typ}, true
+		}
+	}
+	return StationSeed{}, false
+}
+
+// CreateStation creates a station seeded from one or more artists,
+// tracks, genres or playlists, with the server's default mood
+func (c *Client) CreateStation(seeds ...StationSeed) (*Station, error) {
+	return c.createStation(nil, seeds)
+}
+
+// CreateStationWithMood creates a station like CreateStation tuned to
+// the mood
+func (c *Client) CreateStationWithMood(mood StationMood, seeds ...StationSeed) (*Station, error) {
+	return c.createStation(&mood, seeds)
+}
+
+func (c *Client) createStation(mood *StationMood, seeds []StationSeed) (*Station, error) {
+	args, err := createStationArgs(mood, seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.Call("POST", "radio/createStation", args, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return nil, fmt.Errorf("radio/createStation: %s", r.Status)
+	}
+
+	var s Station
+	err = c.decode("radio/createStation", r.Body, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func createStationArgs(mood *StationMood, seeds []StationSeed) (*url.Values, error) {
+	ident, err := SeedsIdent(seeds...)
+	if err != nil {
+		return nil, err
+	}
+
+	args := &url.Values{}
+	args.Set("seeds", string(ident))
+	if mood == nil {
+		return args, nil
+	}
+
+	// The moods are sent as given, their ranges aren't documented
+	for _, m := range []struct {
+		name  string
+		value float64
+	}{
+		{"energy", mood.Energy},
+		{"tempo", mood.Tempo},
+		{"variety", mood.Variety},
+		{"popularity", mood.Popularity},
+	} {
+		args.Set(m.name, strconv.FormatFloat(m.value, 'f', -1, 64))
+	}
+	return args, nil
+}
@@ -0,0 +1,160 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestStationIdents(t *testing.T) {
+	tests := []struct {
+		ident StationIdent
+		want  StationIdent
+	}{
+		{ArtistStation(12), "a12"},
+		{TrackStation(34), "t34"},
+		{GenreStation(5), "g5"},
+		{PlaylistStation(678), "p678"},
+	}
+	for i, test := range tests {
+		if test.ident != test.want {
+			t.Errorf("%d. expected %q got %q", i, test.want, test.ident)
+		}
+	}
+
+	ident, err := SeedsIdent(ArtistSeed(1), TrackSeed(2), GenreSeed(3))
+	if err != nil || ident != "a1_t2_g3" {
+		t.Errorf("expected a1_t2_g3 got %q, %v", ident, err)
+	}
+	if _, err := SeedsIdent(); err != ErrNoSeeds {
+		t.Errorf("expected ErrNoSeeds got %v", err)
+	}
+	if _, err := SeedsIdent(StationSeed{ID: 1, Type: "mood"}); !errors.Is(err, ErrUnknownSeedType) {
+		t.Errorf("expected ErrUnknownSeedType got %v", err)
+	}
+	for _, id := range []int{0, -1} {
+		if ident, err := SeedsIdent(ArtistSeed(1), TrackSeed(TrackID(id))); !errors.Is(err, ErrBadStationIdent) {
+			t.Errorf("expected ErrBadStationIdent for id %d got %q, %v", id, ident, err)
+		}
+	}
+	if ident, err := (StationSeed{ID: 1}).Ident(); !errors.Is(err, ErrUnknownSeedType) {
+		t.Errorf("expected ErrUnknownSeedType for a seed without a type got %q, %v", ident, err)
+	}
+	if ident, err := TrackSeed(9).Ident(); err != nil || ident != "t9" {
+		t.Errorf("expected t9 got %q, %v", ident, err)
+	}
+}
+
+func TestStationIdentRoundTrip(t *testing.T) {
+	for i, seeds := range [][]StationSeed{
+		{ArtistSeed(1)},
+		{TrackSeed(1234567)},
+		{PlaylistSeed(10), GenreSeed(7), ArtistSeed(100)},
+	} {
+		ident, err := SeedsIdent(seeds...)
+		if err != nil {
+			t.Errorf("%d. %v", i, err)
+			continue
+		}
+		got, err := ident.Seeds()
+		if err != nil || !reflect.DeepEqual(got, seeds) {
+			t.Errorf("%d. expected %+v back from %q got %+v, %v", i, seeds, ident, got, err)
+		}
+	}
+}
+
+func TestParseStationIdent(t *testing.T) {
+	tests := []struct {
+		ident StationIdent
+		seeds []StationSeed
+	}{
+		{"a12", []StationSeed{ArtistSeed(12)}},
+		{"p678", []StationSeed{PlaylistSeed(678)}},
+		{"a1_t2_g3", []StationSeed{ArtistSeed(1), TrackSeed(2), GenreSeed(3)}},
+		{"", nil},
+		{"a", nil},
+		{"x12", nil},
+		{"a12_", nil},
+		{"a0", nil},
+		{"g10_t100", []StationSeed{GenreSeed(10), TrackSeed(100)}},
+		{"a-1", nil},
+		{"a+1", nil},
+		{"a-0", nil},
+		{"a+0", nil},
+		{"a007", nil},
+		{"a00", nil},
+		{"a 1", nil},
+		{"a1_t01", nil},
+		{"a99999999999999999999", nil},
+		{"t12a", nil},
+	}
+	for i, test := range tests {
+		seeds, err := test.ident.Seeds()
+		if test.seeds == nil {
+			if !errors.Is(err, ErrBadStationIdent) {
+				t.Errorf("%d. expected ErrBadStationIdent for %q got %v", i, test.ident, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d. %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(seeds, test.seeds) {
+			t.Errorf("%d. expected %+v got %+v", i, test.seeds, seeds)
+		}
+		if ident, _ := SeedsIdent(seeds...); ident != test.ident {
+			t.Errorf("%d. expected %q to round trip got %q", i, test.ident, ident)
+		}
+	}
+}
+
+func TestCreateStation(t *testing.T) {
+	var calls int
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"radio/createStation": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.Method != "POST" {
+				t.Errorf("expected a POST got %s", r.Method)
+			}
+			q := r.URL.Query()
+			resp := map[string]interface{}{
+				"id":    q.Get("seeds"),
+				"moods": map[string]string{"energy": q.Get("energy"), "tempo": q.Get("tempo")},
+				"seeds": []map[string]string{{"id": "7", "name": "Seed", "type": "artist"}},
+			}
+			json.NewEncoder(w).Encode(resp)
+		},
+	})
+
+	s, err := c.CreateStation(ArtistSeed(7), TrackSeed(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "a7_t8" || s.Moods != (StationMood{}) {
+		t.Errorf("unexpected station %+v", s)
+	}
+	if len(s.Seeds) != 1 || s.Seeds[0].Type != SeedArtist || s.Seeds[0].ID != 7 {
+		t.Errorf("unexpected seeds %+v", s.Seeds)
+	}
+
+	s, err = c.CreateStationWithMood(StationMood{Energy: 0.8, Tempo: 0.25}, GenreSeed(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "g3" || s.Moods.Energy != 0.8 || s.Moods.Tempo != 0.25 {
+		t.Errorf("unexpected station %+v", s)
+	}
+
+	if _, err := c.CreateStation(); err != ErrNoSeeds {
+		t.Errorf("expected ErrNoSeeds got %v", err)
+	}
+	if _, err := c.CreateStation(ArtistSeed(0)); !errors.Is(err, ErrBadStationIdent) {
+		t.Errorf("expected ErrBadStationIdent got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected invalid stations not to be sent, got %d calls", calls)
+	}
+}
@@ -1,30 +1,50 @@
 package mediagraft
 
 import (
-	"encoding/json"
 	"net/url"
 )
 
 type StationIdent string
-type StationMood struct{}
-type StationInfluence struct{}
+
+// StationMood tunes how a station's tracks are chosen. The API doesn't
+// document the mood fields, their names and ranges are assumptions.
+type StationMood struct {
+	Energy     float64 `json:"energy,string"`
+	Tempo      float64 `json:"tempo,string"`
+	Variety    float64 `json:"variety,string"`    // How far from the seeds tracks may stray
+	Popularity float64 `json:"popularity,string"` // Hits over deeper cuts
+}
+
+// StationInfluence is an artist, track or genre that the listener's
+// feedback has made more or less likely to be played
+type StationInfluence struct {
+	ID     int     `json:"id,string"`
+	Name   string  `json:"name"`
+	Type   string  `json:"type"` // "artist", "track" or "genre"
+	Weight float64 `json:"weight,string"`
+}
+
+// StationInfluences are a station's influences, what to play more and
+// less of
+type StationInfluences struct {
+	Positive []StationInfluence `json:"positive"`
+	Negative []StationInfluence `json:"negative"`
+}
+
 type StationSeed struct {
-	ID     int    `json:"id,string"`
-	Name   string `json:"name"`
-	Type   string `json:"type"`
-	Images Images `json:"images"`
-	Full   bool   `json:"full"`
+	ID     int      `json:"id,string"`
+	Name   string   `json:"name"`
+	Type   SeedType `json:"type"`
+	Images Images   `json:"images"`
+	Full   bool     `json:"full"`
 }
 
 type Station struct {
-	ID         StationIdent `json:"id"`
-	Cookie     string       `json:"cookie"`
-	Moods      StationMood  `json:"moods"`
-	LinkText   string       `json:"linkText"`
-	Influences struct {
-		Positive []StationInfluence `json:"positive"`
-		Negative []StationInfluence `json:"negative"`
-	} `json:"influences"`
+	ID                StationIdent      `json:"id"`
+	Cookie            string            `json:"cookie"`
+	Moods             StationMood       `json:"moods"`
+	LinkText          string            `json:"linkText"`
+	Influences        StationInfluences `json:"influences"`
 	Tracks            []Track           `json:"tracks"`
 	Artists           []string          `json:"artists"`
 	Description       string            `json:"description"`
@@ -44,21 +64,28 @@ type Station struct {
 }
 
 func (c *Client) GetStation(ident StationIdent) (*Station, error) {
-	args := &url.Values{}
-	args.Set("stationIdent", string(ident))
-
-	r, err := c.Call("GET", "radio/getStation", args, nil)
+	r, err := c.Call("GET", "radio/getStation", getStationArgs(ident), nil)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
 
 	var s Station
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&s)
+	err = c.decode("radio/getStation", r.Body, &s)
 	if err != nil {
 		return nil, err
 	}
 
 	return &s, nil
 }
+
+// GetStationRaw returns the undecoded response to a GetStation call
+func (c *Client) GetStationRaw(ident StationIdent) (*RawResponse, error) {
+	return c.CallRaw("GET", "radio/getStation", getStationArgs(ident), nil)
+}
+
+func getStationArgs(ident StationIdent) *url.Values {
+	args := &url.Values{}
+	args.Set("stationIdent", string(ident))
+	return args
+}
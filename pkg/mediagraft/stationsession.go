@@ -0,0 +1,326 @@
+package mediagraft
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStationBatch is the number of tracks a StationSession
+	// fetches at a time
+	DefaultStationBatch = 5
+	// DefaultStationSkipLimit is the most tracks that may be skipped
+	// in DefaultStationSkipWindow, zero leaves it to the server
+	DefaultStationSkipLimit  = 0
+	DefaultStationSkipWindow = time.Hour
+)
+
+var (
+	ErrSkipLimit       = errors.New("No skips are left on this station")
+	ErrRateLimited     = errors.New("Too many requests, try again later")
+	ErrNoStationTracks = errors.New("The station has no more tracks")
+	ErrNoCurrentTrack  = errors.New("No station track is playing")
+)
+
+// Feedback is the listener's reaction to a station track
+type Feedback string
+
+const (
+	Like    Feedback = "like"
+	Dislike Feedback = "dislike"
+	Skip    Feedback = "skip"
+)
+
+// StationSession plays a radio station: it feeds the station's tracks
+// one at a time and sends the listener's feedback, which changes the
+// station's influences and so the tracks that follow. The server keeps
+// the session's state in a cookie which is passed back on every call.
+//
+// The API doesn't document the session calls: radio/nextTracks,
+// radio/feedback and the skipsRemaining count in their responses are
+// assumptions, as is the server limiting skips at all.
+//
+// A StationSession is safe for concurrent use.
+type StationSession struct {
+	client  *Client
+	station *Station
+
+	batch      int
+	skipLimit  int
+	skipWindow time.Duration
+
+	lock    sync.Mutex
+	cookie  string
+	queue   []Track
+	current *Track
+	skips   []time.Time // When the tracks in the skip window were skipped
+	// The server's count of skips left, -1 until it sends one or once
+	// the skip window has passed since it did
+	serverSkips   int
+	serverSkipsAt time.Time
+}
+
+type sessionOpt func(s *StationSession) sessionOpt
+
+// StartStation fetches the station and starts a session on it. The
+// first track is returned by Next.
+func (c *Client) StartStation(ident StationIdent, opts ...sessionOpt) (*StationSession, error) {
+	st, err := c.GetStation(ident)
+	if err != nil {
+		return nil, err
+	}
+	if st.ID == "" {
+		st.ID = ident
+	}
+
+	s := &StationSession{
+		client:      c,
+		station:     st,
+		batch:       DefaultStationBatch,
+		skipLimit:   DefaultStationSkipLimit,
+		skipWindow:  DefaultStationSkipWindow,
+		cookie:      st.Cookie,
+		queue:       st.Tracks,
+		serverSkips: -1,
+	}
+	s.Option(opts...)
+	return s, nil
+}
+
+// Option sets the options specified.
+// It returns an option to restore the last arg's previous value.
+func (s *StationSession) Option(opts ...sessionOpt) (previous sessionOpt) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, opt := range opts {
+		previous = opt(s)
+	}
+	return previous
+}
+
+// StationBatch sets the number of tracks fetched at a time
+func StationBatch(n int) sessionOpt {
+	return func(s *StationSession) sessionOpt {
+		previous := s.batch
+		s.batch = n
+		return StationBatch(previous)
+	}
+}
+
+// SkipLimit sets the most tracks that may be skipped in window, zero
+// leaves it to the server. The server's count of skips left is only
+// trusted for window, or DefaultStationSkipWindow if that's zero.
+func SkipLimit(n int, window time.Duration) sessionOpt {
+	return func(s *StationSession) sessionOpt {
+		previousN, previousWindow := s.skipLimit, s.skipWindow
+		s.skipLimit, s.skipWindow = n, window
+		return SkipLimit(previousN, previousWindow)
+	}
+}
+
+// Station returns the station as it was when the session started,
+// except for its influences which are kept up to date with feedback
+func (s *StationSession) Station() *Station {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	st := *s.station
+	return &st
+}
+
+// Influences returns the station's current influences
+func (s *StationSession) Influences() StationInfluences {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.station.Influences
+}
+
+// Current returns the track playing, nil before the first call to
+// Next
+func (s *StationSession) Current() *Track {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.current
+}
+
+// Upcoming returns the tracks already fetched that will play next
+func (s *StationSession) Upcoming() []Track {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Track(nil), s.queue...)
+}
+
+// Next moves on to the next track, fetching more from the server when
+// none are left. It returns ErrNoStationTracks when the station has
+// run out.
+func (s *StationSession) Next() (*Track, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.next()
+}
+
+func (s *StationSession) next() (*Track, error) {
+	if len(s.queue) == 0 {
+		if err := s.fetch(); err != nil {
+			return nil, err
+		}
+		if len(s.queue) == 0 {
+			return nil, ErrNoStationTracks
+		}
+	}
+	t := s.queue[0]
+	s.queue = s.queue[1:]
+	s.current = &t
+	return s.current, nil
+}
+
+// Like tells the station the listener likes the current track
+func (s *StationSession) Like() error {
+	return s.Feedback(Like)
+}
+
+// Dislike tells the station the listener dislikes the current track,
+// it carries on playing
+func (s *StationSession) Dislike() error {
+	return s.Feedback(Dislike)
+}
+
+// Skip skips the current track and moves on to the next. It returns
+// ErrSkipLimit, without skipping, if no skips are left. Once the server
+// has accepted the skip it counts against the limit, even if fetching
+// the next track then fails, Next may be called to try again.
+func (s *StationSession) Skip() (*Track, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.skipsRemaining() == 0 {
+		return nil, ErrSkipLimit
+	}
+	if err := s.feedback(Skip); err != nil {
+		return nil, err
+	}
+	s.skips = append(s.skips, time.Now())
+	return s.next()
+}
+
+// Feedback sends feedback on the current track. Skip feedback doesn't
+// move on to the next track, use Skip for that.
+func (s *StationSession) Feedback(f Feedback) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.feedback(f)
+}
+
+// SkipsRemaining returns the number of tracks that may be skipped now,
+// or -1 if there's no limit
+func (s *StationSession) SkipsRemaining() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.skipsRemaining()
+}
+
+func (s *StationSession) skipsRemaining() int {
+	if s.serverSkips >= 0 && time.Since(s.serverSkipsAt) < s.serverSkipsWindow() {
+		return s.serverSkips
+	}
+	s.serverSkips = -1
+	if s.skipLimit <= 0 {
+		return -1
+	}
+
+	since := time.Now().Add(-s.skipWindow)
+	for len(s.skips) != 0 && s.skips[0].Before(since) {
+		s.skips = s.skips[1:]
+	}
+	if n := s.skipLimit - len(s.skips); n > 0 {
+		return n
+	}
+	return 0
+}
+
+func (s *StationSession) serverSkipsWindow() time.Duration {
+	if s.skipWindow <= 0 {
+		return DefaultStationSkipWindow
+	}
+	return s.skipWindow
+}
+
+func (s *StationSession) setServerSkips(n int) {
+	s.serverSkips = n
+	s.serverSkipsAt = time.Now()
+}
+
+// stationUpdate is the response to the session calls, each field is
+// only sent if it's changed
+type stationUpdate struct {
+	Cookie         string             `json:"cookie"`
+	Tracks         []Track            `json:"tracks"`
+	Influences     *StationInfluences `json:"influences"`
+	SkipsRemaining *int               `json:"skipsRemaining,string"`
+}
+
+func (s *StationSession) fetch() error {
+	args := s.args()
+	args.Set("count", strconv.Itoa(s.batch))
+
+	u, err := s.call("GET", "radio/nextTracks", args)
+	if err != nil {
+		return err
+	}
+	s.queue = append(s.queue, u.Tracks...)
+	return nil
+}
+
+func (s *StationSession) feedback(f Feedback) error {
+	if s.current == nil {
+		return ErrNoCurrentTrack
+	}
+	args := s.args()
+	args.Set("trackId", strconv.Itoa(int(s.current.Id)))
+	args.Set("feedback", string(f))
+
+	_, err := s.call("POST", "radio/feedback", args)
+	return err
+}
+
+func (s *StationSession) args() *url.Values {
+	args := getStationArgs(s.station.ID)
+	args.Set("cookie", s.cookie)
+	return args
+}
+
+// call makes a session call and applies the update it returns
+func (s *StationSession) call(httpmethod, method string, args *url.Values) (*stationUpdate, error) {
+	r, err := s.client.Call(httpmethod, method, args, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	switch {
+	case r.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%s: %w", method, ErrRateLimited)
+	case r.StatusCode < 200 || r.StatusCode > 299:
+		return nil, fmt.Errorf("%s: %s", method, r.Status)
+	}
+
+	var u stationUpdate
+	if err := s.client.decode(method, r.Body, &u); err != nil {
+		return nil, err
+	}
+
+	if u.Cookie != "" {
+		s.cookie = u.Cookie
+	}
+	if u.Influences != nil {
+		s.station.Influences = *u.Influences
+	}
+	if u.SkipsRemaining != nil {
+		s.setServerSkips(*u.SkipsRemaining)
+	}
+	return &u, nil
+}
@@ -0,0 +1,291 @@
+package mediagraft
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRadio serves a station whose tracks are numbered from 1, along
+// with the session calls. Each call's cookie must be the one sent by
+// the previous call. If skips is not negative the server enforces it
+// as the number of skips left. While limited every session call is
+// refused as too many requests.
+type fakeRadio struct {
+	t       *testing.T
+	tracks  int // The station runs out after this many tracks
+	skips   int
+	limited bool
+
+	lock       sync.Mutex
+	sent       int
+	cookie     int
+	influences StationInfluences
+	feedback   []string
+}
+
+func (f *fakeRadio) handlers() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"radio/getStation": func(w http.ResponseWriter, r *http.Request) {
+			f.lock.Lock()
+			defer f.lock.Unlock()
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":     r.URL.Query().Get("stationIdent"),
+				"name":   "Test radio",
+				"cookie": f.nextCookie(),
+				"tracks": f.next(2),
+			})
+		},
+		"radio/nextTracks": f.session(func(w http.ResponseWriter, r *http.Request) {
+			n, _ := strconv.Atoi(r.URL.Query().Get("count"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"cookie": f.nextCookie(),
+				"tracks": f.next(n),
+			})
+		}),
+		"radio/feedback": f.session(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			fb, id := q.Get("feedback"), q.Get("trackId")
+			if fb == "skip" && f.skips == 0 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			f.feedback = append(f.feedback, fb+" "+id)
+
+			resp := map[string]interface{}{"cookie": f.nextCookie()}
+			n, _ := strconv.Atoi(id)
+			in := StationInfluence{ID: n, Name: "track " + id, Type: "track", Weight: 1}
+			switch fb {
+			case "like":
+				f.influences.Positive = append(f.influences.Positive, in)
+				resp["influences"] = f.influences
+			case "dislike":
+				f.influences.Negative = append(f.influences.Negative, in)
+				resp["influences"] = f.influences
+			case "skip":
+				if f.skips > 0 {
+					f.skips--
+					resp["skipsRemaining"] = strconv.Itoa(f.skips)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		}),
+	}
+}
+
+// session wraps a session call, checking the station and cookie
+func (f *fakeRadio) session(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		q := r.URL.Query()
+		if q.Get("stationIdent") != "a1" {
+			f.t.Errorf("expected station a1 got %q", q.Get("stationIdent"))
+		}
+		if want := strconv.Itoa(f.cookie); q.Get("cookie") != want {
+			f.t.Errorf("expected cookie %s got %q", want, q.Get("cookie"))
+		}
+		if f.limited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (f *fakeRadio) nextCookie() string {
+	f.cookie++
+	return strconv.Itoa(f.cookie)
+}
+
+func (f *fakeRadio) next(n int) []map[string]string {
+	ts := []map[string]string{}
+	for ; n > 0 && f.sent < f.tracks; n-- {
+		f.sent++
+		ts = append(ts, map[string]string{"trackId": strconv.Itoa(f.sent)})
+	}
+	return ts
+}
+
+func TestStationSession(t *testing.T) {
+	f := &fakeRadio{t: t, tracks: 7, skips: -1}
+	c := newTestClient(t, f.handlers())
+
+	s, err := c.StartStation("a1", StationBatch(3), SkipLimit(2, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Station().Name != "Test radio" || s.Current() != nil || len(s.Upcoming()) != 2 {
+		t.Fatalf("unexpected session start %+v", s.Station())
+	}
+	if err := s.Like(); err != ErrNoCurrentTrack {
+		t.Errorf("expected ErrNoCurrentTrack got %v", err)
+	}
+
+	steps := []struct {
+		do    func() (*Track, error)
+		track TrackID
+	}{
+		{s.Next, 1},
+		{func() (*Track, error) { return nil, s.Like() }, 1},
+		{s.Next, 2},
+		{func() (*Track, error) { return nil, s.Dislike() }, 2},
+		{s.Skip, 3}, // Fetches tracks 3 to 5
+		{s.Skip, 4},
+		{s.Next, 5},
+		{s.Next, 6}, // Fetches tracks 6 and 7
+		{s.Next, 7},
+	}
+	for i, step := range steps {
+		tr, err := step.do()
+		if err != nil {
+			t.Fatalf("%d. %v", i, err)
+		}
+		if tr != nil && tr.Id != step.track {
+			t.Errorf("%d. expected track %d got %d", i, step.track, tr.Id)
+		}
+		if cur := s.Current(); cur.Id != step.track {
+			t.Errorf("%d. expected current track %d got %d", i, step.track, cur.Id)
+		}
+	}
+
+	in := s.Influences()
+	if len(in.Positive) != 1 || in.Positive[0].ID != 1 || len(in.Negative) != 1 || in.Negative[0].ID != 2 {
+		t.Errorf("unexpected influences %+v", in)
+	}
+	if n := s.SkipsRemaining(); n != 0 {
+		t.Errorf("expected no skips remaining got %d", n)
+	}
+	if _, err := s.Skip(); err != ErrSkipLimit {
+		t.Errorf("expected ErrSkipLimit got %v", err)
+	}
+	if cur := s.Current(); cur.Id != 7 {
+		t.Errorf("expected the skip limit to keep track 7 got %d", cur.Id)
+	}
+	if _, err := s.Next(); err != ErrNoStationTracks {
+		t.Errorf("expected ErrNoStationTracks got %v", err)
+	}
+
+	want := []string{"like 1", "dislike 2", "skip 2", "skip 3"}
+	if len(f.feedback) != len(want) {
+		t.Fatalf("expected feedback %v got %v", want, f.feedback)
+	}
+	for i := range want {
+		if f.feedback[i] != want[i] {
+			t.Errorf("%d. expected feedback %q got %q", i, want[i], f.feedback[i])
+		}
+	}
+}
+
+func TestStationSessionServerSkips(t *testing.T) {
+	f := &fakeRadio{t: t, tracks: 10, skips: 1}
+	c := newTestClient(t, f.handlers())
+
+	s, err := c.StartStation("a1", SkipLimit(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.SkipsRemaining(); n != -1 {
+		t.Errorf("expected no limit before the server sends one got %d", n)
+	}
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.SkipsRemaining(); n != 0 {
+		t.Errorf("expected the server's count of 0 got %d", n)
+	}
+	if _, err := s.Skip(); err != ErrSkipLimit {
+		t.Errorf("expected ErrSkipLimit got %v", err)
+	}
+
+	// The server refusing a skip the client thought it had is only
+	// rate limiting, it isn't taken as the skip limit
+	f.skips = 0
+	s.serverSkips = -1
+	if _, err := s.Skip(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited from the server got %v", err)
+	}
+	if n := s.SkipsRemaining(); n != -1 {
+		t.Errorf("expected the refusal not to set a limit got %d", n)
+	}
+	if cur := s.Current(); cur.Id != 2 {
+		t.Errorf("expected the refused skip to keep track 2 got %d", cur.Id)
+	}
+}
+
+func TestStationSessionServerSkipsExpire(t *testing.T) {
+	f := &fakeRadio{t: t, tracks: 10, skips: 1}
+	c := newTestClient(t, f.handlers())
+
+	s, err := c.StartStation("a1", SkipLimit(0, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Skip(); err != ErrSkipLimit {
+		t.Errorf("expected ErrSkipLimit from the server's count got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if n := s.SkipsRemaining(); n != -1 {
+		t.Errorf("expected the server's count to expire after the window got %d", n)
+	}
+
+	f.skips = 1
+	if tr, err := s.Skip(); err != nil || tr.Id != 3 {
+		t.Errorf("expected to skip to track 3 got %v, %v", tr, err)
+	}
+}
+
+func TestStationSessionRateLimited(t *testing.T) {
+	f := &fakeRadio{t: t, tracks: 10, skips: -1}
+	c := newTestClient(t, f.handlers())
+
+	s, err := c.StartStation("a1", StationBatch(1), SkipLimit(2, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.lock.Lock()
+	f.limited = true
+	f.lock.Unlock()
+
+	if err := s.Like(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited liking got %v", err)
+	}
+	if _, err := s.Next(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited fetching tracks got %v", err)
+	}
+	if n := s.SkipsRemaining(); n != 2 {
+		t.Errorf("expected rate limiting not to use up skips got %d left", n)
+	}
+
+	f.lock.Lock()
+	f.limited = false
+	f.lock.Unlock()
+
+	if tr, err := s.Next(); err != nil || tr.Id != 3 {
+		t.Errorf("expected track 3 once the rate limit passed got %v, %v", tr, err)
+	}
+	if _, err := s.Skip(); err != nil {
+		t.Errorf("expected skips to still work got %v", err)
+	}
+}
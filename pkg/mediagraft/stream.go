@@ -1,32 +1,21 @@
 package mediagraft
 
 import (
-	"encoding/json"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func (c *Client) StreamInfo(trackId int, playSource string, playlistId int, musicFormats []string) (*Stream, error) {
-	args := &url.Values{}
-	args.Set("trackId", strconv.Itoa(trackId))
-	args.Set("playSource", playSource)
-	if playSource == "PLAYLIST" {
-		args.Set("playlistId", strconv.Itoa(playlistId))
-	}
-	args.Set("musicFormats", strings.Join(musicFormats, ","))
-
-	r, err := c.Call("GET", "streaming/streamInfoWithOAuth", args, nil)
+func (c *Client) StreamInfo(trackId TrackID, playSource string, playlistId PlaylistID, musicFormats []string) (*Stream, error) {
+	r, err := c.Call("GET", "streaming/streamInfoWithOAuth", streamInfoArgs(trackId, playSource, playlistId, musicFormats), nil)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
 
 	var s Stream
-	//io.Copy(os.Stdout, r.Body)
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&s)
+	err = c.decode("streaming/streamInfoWithOAuth", r.Body, &s)
 	if err != nil {
 		return nil, err
 	}
@@ -34,6 +23,22 @@ func (c *Client) StreamInfo(trackId int, playSource string, playlistId int, musi
 	return &s, nil
 }
 
+// StreamInfoRaw returns the undecoded response to a StreamInfo call
+func (c *Client) StreamInfoRaw(trackId TrackID, playSource string, playlistId PlaylistID, musicFormats []string) (*RawResponse, error) {
+	return c.CallRaw("GET", "streaming/streamInfoWithOAuth", streamInfoArgs(trackId, playSource, playlistId, musicFormats), nil)
+}
+
+func streamInfoArgs(trackId TrackID, playSource string, playlistId PlaylistID, musicFormats []string) *url.Values {
+	args := &url.Values{}
+	args.Set("trackId", strconv.Itoa(int(trackId)))
+	args.Set("playSource", playSource)
+	if playSource == "PLAYLIST" {
+		args.Set("playlistId", strconv.Itoa(int(playlistId)))
+	}
+	args.Set("musicFormats", strings.Join(musicFormats, ","))
+	return args
+}
+
 func (c *Client) StreamEnd(u StreamUnique, played, paused time.Duration) error {
 	args := &url.Values{}
 	args.Set("streamUnique", string(u))
@@ -1,63 +1,39 @@
 package mediagraft
 
-import (
-	"encoding/json"
-	"net/url"
-	"strconv"
-	"strings"
-)
-
-func (c *Client) TracksInfo(trackId ...int32) ([]Track, error) {
-	args := &url.Values{}
-
-	var strids []string
-	for _, t := range trackId {
-		strids = append(strids, strconv.Itoa(int(t)))
-	}
-	args.Set("ids", strings.Join(strids, ","))
-	args.Set("detail", "full")
-
-	r, err := c.Call("GET", "tracksInfo", args, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Body.Close()
-
-	var t []Track
-	//io.Copy(os.Stdout, r.Body)
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&t)
-	if err != nil {
-		return nil, err
-	}
-
-	return t, nil
+// TracksInfo returns the tracks with the given ids, in the same order.
+// If some tracks were not found the others are returned along with a
+// *PartialResultError.
+func (c *Client) TracksInfo(trackId ...TrackID) ([]Track, error) {
+	return lookup(c, tracksLookup, intIDs(trackId))
 }
 
-func (c *Client) TrackVersionsInfo(trackId ...int32) ([]Track, error) {
-	args := &url.Values{}
-
-	var strids []string
-	for _, t := range trackId {
-		strids = append(strids, strconv.Itoa(int(t)))
-	}
+// TracksInfoRaw returns the undecoded response to a single tracksInfo
+// call for the ids
+func (c *Client) TracksInfoRaw(trackId ...TrackID) (*RawResponse, error) {
+	return tracksLookup.raw(c, intIDs(trackId))
+}
 
-	args.Set("versionIds", strings.Join(strids, ","))
-	args.Set("detail", "full")
+// TracksInfoIter returns an iterator over the tracks with the given
+// ids, decoding them as they are read
+func (c *Client) TracksInfoIter(trackId ...TrackID) *Iterator[Track] {
+	return tracksLookup.iter(c, intIDs(trackId))
+}
 
-	r, err := c.Call("GET", "tracksInfo", args, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Body.Close()
+// TrackVersionsInfo returns the tracks with the given track version
+// ids, in the same order. If some tracks were not found the others are
+// returned along with a *PartialResultError.
+func (c *Client) TrackVersionsInfo(versionId ...TrackVersionID) ([]Track, error) {
+	return lookup(c, trackVersionsLookup, intIDs(versionId))
+}
 
-	var t []Track
-	//io.Copy(os.Stdout, r.Body)
-	dec := json.NewDecoder(r.Body)
-	err = dec.Decode(&t)
-	if err != nil {
-		return nil, err
-	}
+// TrackVersionsInfoRaw returns the undecoded response to a single
+// tracksInfo call for the track version ids
+func (c *Client) TrackVersionsInfoRaw(versionId ...TrackVersionID) (*RawResponse, error) {
+	return trackVersionsLookup.raw(c, intIDs(versionId))
+}
 
-	return t, nil
+// TrackVersionsInfoIter returns an iterator over the tracks with the
+// given track version ids, decoding them as they are read
+func (c *Client) TrackVersionsInfoIter(versionId ...TrackVersionID) *Iterator[Track] {
+	return trackVersionsLookup.iter(c, intIDs(versionId))
 }
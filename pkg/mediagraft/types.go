@@ -1,6 +1,9 @@
 package mediagraft
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // ImageSize is a string representation of hte size of an image available
 // from the image store. This should be either "original", or "WxH"
@@ -11,111 +14,157 @@ type URL string
 // Images is a set of image URLs keyed by size
 type Images map[ImageSize]URL
 
+// Largest returns the original image if there is one, otherwise the
+// largest by area, or "" if there are none
+func (i Images) Largest() URL {
+	if u, ok := i["original"]; ok {
+		return u
+	}
+	var (
+		best URL
+		area = -1
+	)
+	for size, u := range i {
+		var w, h int
+		fmt.Sscanf(string(size), "%dx%d", &w, &h)
+		if w*h > area || (w*h == area && u < best) {
+			best, area = u, w*h
+		}
+	}
+	return best
+}
+
+// Typed identifiers for the catalog entities, so an album id can't be
+// passed where a track id is expected
+type (
+	TrackID        int
+	TrackVersionID int
+	AlbumID        int
+	ArtistID       int
+	GenreID        int
+	RadioStationID int
+	PlaylistID     int
+	UserID         int
+)
+
+// ArtistRef identifies the artist of a track or album. The server
+// sends these fields inline in the track or album object.
+type ArtistRef struct {
+	Id          ArtistID `json:"artistId,string"`
+	Name        string   `json:"artistName"`
+	DisplayName string   `json:"artistDisplayName,omitempty"`
+}
+
+// AlbumRef identifies the album a track appears on. The server sends
+// these fields inline in the track object.
+type AlbumRef struct {
+	Id    AlbumID `json:"albumId,string"`
+	Title string  `json:"albumTitle"`
+}
+
 type Artist struct {
-	Id          int    `json:"artistId,string"`
-	Name        string `json:"artistName"`
-	DisplayName string `json:"artistDisplayName"`
-	Images      Images
-	Streamable  bool `json:"streamable,string"`
+	Id          ArtistID `json:"artistId,string"`
+	Name        string   `json:"artistName"`
+	DisplayName string   `json:"artistDisplayName"`
+	Images      Images   `json:"images"`
+	Streamable  bool     `json:"streamable,string"`
 
 	IsAlikeTitleMatch  bool `json:"isAlikeTitleMatch,string"`
-	IsAlikeArtistMatch bool `json:"isAlikeTitleMatch,string"`
+	IsAlikeArtistMatch bool `json:"isAlikeArtistMatch,string"`
 
 	Description      string `json:"artistDescription"`
-	URL              URL
-	IsVarious        bool `json:"isVarious,string"`
-	CommentaryArtist bool `json:"commentaryArtist,string"`
+	URL              URL    `json:"url"`
+	IsVarious        bool   `json:"isVarious,string"`
+	CommentaryArtist bool   `json:"commentaryArtist,string"`
 
-	Genres []Genre `json:"grenres"`
+	Genres []Genre `json:"genres"`
 	Albums []Album `json:"albums"`
 	Tracks []Track `json:"tracks"`
 }
 
 type Track struct {
-	Id         int    `json:"trackId,string"`
-	Title      string `json:"trackTitle"`
-	Images     Images
+	Id         TrackID       `json:"trackId,string"`
+	Title      string        `json:"trackTitle"`
+	Images     Images        `json:"images"`
 	Streamable bool          `json:"streamable,string"`
-	duration   int           `json:"duration,string"`
-	Duration   time.Duration `json:"-"`
-
-	Purchaseable   bool `json:"purchaseable,string"`
-	Radioable      bool `json:"radioable,string"`
-	CopyRight      string
-	OwnerId        int `json:"ownerId,string"`
-	PurchasPrice   string
-	TrackVersionId int  `json:"trackVersionId,string"`
-	TrackNumber    int  `json:"trackNumber,string"`
-	DiscNumber     int  `json:"discNumber,string"`
-	Explicit       bool `json:"explicit,string"`
-
-	Genres []Genre
-	Artist
-	Album
+	Duration   time.Duration `json:"-"` // Decoded from duration (seconds) or durationMs
+
+	Purchaseable   bool           `json:"purchaseable,string"`
+	Radioable      bool           `json:"radioable,string"`
+	CopyRight      string         `json:"copyRight"`
+	OwnerId        int            `json:"ownerId,string"`
+	PurchasPrice   string         `json:"purchasPrice"`
+	TrackVersionId TrackVersionID `json:"trackVersionId,string"`
+	TrackNumber    int            `json:"trackNumber,string"`
+	DiscNumber     int            `json:"discNumber,string"`
+	Explicit       bool           `json:"explicit,string"`
+
+	Genres []Genre    `json:"genres"`
+	Artist *ArtistRef `json:"-"` // Decoded from the inline artist fields
+	Album  *AlbumRef  `json:"-"` // Decoded from the inline album fields
 
 	IsAlikeTitleMatch  bool `json:"isAlikeTitleMatch,string"`
-	IsAlikeArtistMatch bool `json:"isAlikeTitleMatch,string"`
+	IsAlikeArtistMatch bool `json:"isAlikeArtistMatch,string"`
 }
 
 type TrackVersion struct {
-	Id         int    `json:"trackId,string"`
-	Title      string `json:"trackVersionTitle"`
-	Images     Images
+	Id         TrackID       `json:"trackId,string"`
+	Title      string        `json:"trackVersionTitle"`
+	Images     Images        `json:"images"`
 	Streamable bool          `json:"streamable,string"`
-	duration   int           `json:"duration,string"`
-	Duration   time.Duration `json:"-"`
+	Duration   time.Duration `json:"-"` // Decoded from duration (seconds) or durationMs
 
-	Artist
+	Artist *ArtistRef `json:"-"` // Decoded from the inline artist fields
 
 	IsAlikeTitleMatch  bool `json:"isAlikeTitleMatch,string"`
-	IsAlikeArtistMatch bool `json:"isAlikeTitleMatch,string"`
+	IsAlikeArtistMatch bool `json:"isAlikeArtistMatch,string"`
 }
 
 type Album struct {
-	Id         int    `json:"albumId,string"`
-	Title      string `json:"albumTitle"`
-	Images     Images
-	Streamable bool `json:"streamable,string"`
+	Id         AlbumID `json:"albumId,string"`
+	Title      string  `json:"albumTitle"`
+	Images     Images  `json:"images"`
+	Streamable bool    `json:"streamable,string"`
 
-	Artist
-	Genres []Genre `json:"grenres"`
-	Tracks []Track `json:"tracks"`
+	Artist *ArtistRef `json:"-"` // Decoded from the inline artist fields
+	Genres []Genre    `json:"genres"`
+	Tracks []Track    `json:"tracks"`
 
-	Composer   string
-	ComposerID int `json:"composerId,string"`
+	Composer   string `json:"composer"`
+	ComposerID int    `json:"composerId,string"`
 
 	IsAlikeTitleMatch  bool `json:"isAlikeTitleMatch,string"`
-	IsAlikeArtistMatch bool `json:"isAlikeTitleMatch,string"`
+	IsAlikeArtistMatch bool `json:"isAlikeArtistMatch,string"`
 }
 
 type RadioStation struct {
-	Id         int    `json:"stationId,string"`
-	Name       string `json:"stationName"`
-	Images     Images
-	Promoted   bool `json:"promoted,string"`
-	Streamable bool `json:"streamable,string"`
+	Id         RadioStationID `json:"stationId,string"`
+	Name       string         `json:"stationName"`
+	Images     Images         `json:"images"`
+	Promoted   bool           `json:"promoted,string"`
+	Streamable bool           `json:"streamable,string"`
 
 	IsAlikeTitleMatch  bool `json:"isAlikeTitleMatch,string"`
-	IsAlikeArtistMatch bool `json:"isAlikeTitleMatch,string"`
+	IsAlikeArtistMatch bool `json:"isAlikeArtistMatch,string"`
 }
 
 type Genre struct {
-	Id         int    `json:"genreId,string"`
-	Name       string `json:"genreName"`
-	Images     Images
-	Streamable bool `json:"streamable,string"`
+	Id         GenreID `json:"genreId,string"`
+	Name       string  `json:"genreName"`
+	Images     Images  `json:"images"`
+	Streamable bool    `json:"streamable,string"`
 
 	IsAlikeTitleMatch  bool `json:"isAlikeTitleMatch,string"`
-	IsAlikeArtistMatch bool `json:"isAlikeTitleMatch,string"`
+	IsAlikeArtistMatch bool `json:"isAlikeArtistMatch,string"`
 }
 
 type Playlist struct {
-	Id          int    `json:"playlistId,string"`
-	Name        string `json:"playlistName"`
-	Description string `json:"playlistDescription"`
-	Version     int    `json:"playlistVersion,string"`
+	Id          PlaylistID `json:"playlistId,string"`
+	Name        string     `json:"playlistName"`
+	Description string     `json:"playlistDescription"`
+	Version     int        `json:"playlistVersion,string"`
 
-	Images Images
+	Images Images  `json:"images"`
 	Tracks []Track `json:"tracks"`
 
 	User
@@ -123,13 +172,13 @@ type Playlist struct {
 
 type StreamUnique string
 type Stream struct {
-	Id       int          `json:"trackId,string"`
+	Id       TrackID      `json:"trackId,string"`
 	Unique   StreamUnique `json:"streamUnique"`
 	Location URL          `json:"streamLocation"`
-	Format   string
+	Format   string       `json:"format"`
 }
 
 type User struct {
-	UserId   int    `json:"userId,string"`
+	UserId   UserID `json:"userId,string"`
 	UserName string `json:"userName"`
 }
@@ -0,0 +1,231 @@
+package mediagraft
+
+import (
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// WireFormat is the format responses are requested in
+type WireFormat string
+
+const (
+	JSON WireFormat = "json"
+	XML  WireFormat = "xml"
+)
+
+// ResponseFormat sets the format responses are requested in. Both
+// formats decode into the same types.
+func ResponseFormat(f WireFormat) option {
+	return func(c *Client) option {
+		previous := c.format
+		c.format = f
+		return ResponseFormat(previous)
+	}
+}
+
+// ResponseFormat returns the format responses are requested in
+func (c *Client) ResponseFormat() WireFormat {
+	if c.format == "" {
+		return JSON
+	}
+	return c.format
+}
+
+// XML responses carry the same fields as JSON ones, as attributes or
+// child elements named after the JSON keys. Arrays are wrapped in an
+// element named after the field, with one child element per item, e.g.
+//
+//	<genres><genre><genreId>7</genreId></genre></genres>
+//
+// and maps have one child element per entry, keyed by its key, size
+// or name attribute, or else its element name e.g.
+//
+//	<images><image size="100x100">http://...</image></images>
+//
+// The document is converted to the generic values encoding/json
+// produces, guided by the type being decoded into, and then decoded
+// like a JSON response. A missing element is equivalent to null.
+
+// decodeXML leniently decodes the XML response to method from r into v
+func decodeXML(method string, r io.Reader, v interface{}, d *DriftRecorder) error {
+	root, err := parseXML(r)
+	if err != nil {
+		return err
+	}
+	return normalizeInto(&normalizer{method: method, drift: d, text: true}, root.value(reflect.TypeOf(v)), v, "")
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// parseXML reads the document's root element from r
+func parseXML(r io.Reader) (*xmlNode, error) {
+	dec := xml.NewDecoder(r)
+	start, err := nextElement(dec)
+	if err != nil {
+		return nil, err
+	}
+	if start == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return parseElement(dec, *start)
+}
+
+// nextElement returns the next start element at the current level, or
+// nil when the enclosing element ends
+func nextElement(dec *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			return &tok, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// parseElement reads the element started by start
+func parseElement(dec *xml.Decoder, start xml.StartElement) (*xmlNode, error) {
+	stack := []*xmlNode{newXMLNode(start)}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := newXMLNode(tok)
+			p := stack[len(stack)-1]
+			p.children = append(p.children, n)
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return n, nil
+			}
+
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(tok)
+		}
+	}
+}
+
+func newXMLNode(start xml.StartElement) *xmlNode {
+	n := &xmlNode{name: start.Name.Local}
+	for _, a := range start.Attr {
+		if a.Name.Space != "xmlns" && a.Name.Local != "xmlns" {
+			n.attrs = append(n.attrs, a)
+		}
+	}
+	return n
+}
+
+// value converts the element to the generic value encoding/json would
+// decode the equivalent JSON into, for decoding into t
+func (n *xmlNode) value(t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	_, inline := inlineFields[t]
+	if !inline && reflect.PtrTo(t).Implements(unmarshalerType) {
+		return n.generic()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := jsonFields(t)
+		m := n.attrMap()
+		for _, c := range n.children {
+			if f, ok := fields[strings.ToLower(c.name)]; ok {
+				m[c.name] = c.value(f.typ)
+			} else {
+				m[c.name] = c.generic()
+			}
+		}
+		return m
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return n.text.String()
+		}
+		a := make([]interface{}, 0, len(n.children))
+		for _, c := range n.children {
+			a = append(a, c.value(t.Elem()))
+		}
+		return a
+
+	case reflect.Map:
+		m := make(map[string]interface{}, len(n.children))
+		for _, c := range n.children {
+			m[c.key()] = c.value(t.Elem())
+		}
+		return m
+
+	case reflect.String:
+		return n.text.String()
+	}
+
+	return strings.TrimSpace(n.text.String())
+}
+
+// generic converts an element without a known type: its text if it
+// has no children, otherwise an object with repeated children
+// collected into arrays
+func (n *xmlNode) generic() interface{} {
+	if len(n.children) == 0 && len(n.attrs) == 0 {
+		return strings.TrimSpace(n.text.String())
+	}
+	m := n.attrMap()
+	for _, c := range n.children {
+		switch e := m[c.name].(type) {
+		case nil:
+			m[c.name] = c.generic()
+		case []interface{}:
+			m[c.name] = append(e, c.generic())
+		default:
+			m[c.name] = []interface{}{e, c.generic()}
+		}
+	}
+	return m
+}
+
+func (n *xmlNode) attrMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(n.attrs)+len(n.children))
+	for _, a := range n.attrs {
+		m[a.Name.Local] = a.Value
+	}
+	return m
+}
+
+// key returns the key of a map entry element
+func (n *xmlNode) key() string {
+	for _, name := range []string{"key", "size", "name"} {
+		for _, a := range n.attrs {
+			if a.Name.Local == name {
+				return a.Value
+			}
+		}
+	}
+	return n.name
+}
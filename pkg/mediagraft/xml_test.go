@@ -0,0 +1,125 @@
+package mediagraft
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXMLFixtureParity(t *testing.T) {
+	for i, tc := range []struct {
+		fixture string
+		v       func() interface{}
+	}{
+		{"tracksInfo", func() interface{} { return &[]Track{} }},
+		{"albumsInfo", func() interface{} { return &[]Album{} }},
+	} {
+		j, x := tc.v(), tc.v()
+		decodeFixture(t, "testdata/"+tc.fixture+".json", j, decodeLenient)
+		decodeFixture(t, "testdata/"+tc.fixture+".xml", x, decodeXML)
+		if !reflect.DeepEqual(j, x) {
+			t.Errorf("%d. %s: xml decoded\n%+v\njson decoded\n%+v", i, tc.fixture, x, j)
+		}
+	}
+}
+
+func decodeFixture(t *testing.T, path string, v interface{}, decode func(string, io.Reader, interface{}, *DriftRecorder) error) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := decode("fixture", f, v, nil); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+func TestXMLParity(t *testing.T) {
+	for i, tc := range []struct {
+		json, xml string
+		v         func() interface{}
+	}{
+		{
+			`{"artistId": "42", "artistName": "Jimi Hendrix", "images": {"100x100": "a.jpg", "original": "b.jpg"}, "streamable": true}`,
+			`<artist artistId="42"><artistName>Jimi Hendrix</artistName><images><image size="100x100">a.jpg</image><original>b.jpg</original></images><streamable>true</streamable></artist>`,
+			func() interface{} { return &Artist{} },
+		},
+		{
+			`{"stationId": "3", "stationName": "Blues", "promoted": "true"}`,
+			`<radioStation><stationId>3</stationId><stationName>Blues</stationName><promoted>true</promoted></radioStation>`,
+			func() interface{} { return &RadioStation{} },
+		},
+		{
+			`{"playlistId": "9", "playlistName": "Mine", "playlistVersion": "2", "userId": "5", "userName": "tom", "tracks": [{"trackId": "1"}, {"trackId": "2"}]}`,
+			`<playlist playlistId="9" playlistVersion="2"><playlistName>Mine</playlistName><userId>5</userId><userName>tom</userName><tracks><track trackId="1"/><track trackId="2"/></tracks></playlist>`,
+			func() interface{} { return &Playlist{} },
+		},
+		{
+			`{"trackId": "1", "streamUnique": "u1", "streamLocation": "http://s.example.com/1.mp3", "format": "mp3"}`,
+			`<stream><trackId>1</trackId><streamUnique>u1</streamUnique><streamLocation>http://s.example.com/1.mp3</streamLocation><format>mp3</format></stream>`,
+			func() interface{} { return &Stream{} },
+		},
+		{
+			`{"id": "a1", "searchable": "true", "popularity": "0.5", "explicitCount": 2}`,
+			`<station id="a1" searchable="true"><popularity>0.5</popularity><explicitCount>2</explicitCount></station>`,
+			func() interface{} { return &Station{} },
+		},
+	} {
+		j, x := tc.v(), tc.v()
+		if err := decodeLenient("test", strings.NewReader(tc.json), j, nil); err != nil {
+			t.Errorf("%d. json: %v", i, err)
+			continue
+		}
+		if err := decodeXML("test", strings.NewReader(tc.xml), x, nil); err != nil {
+			t.Errorf("%d. xml: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(j, x) {
+			t.Errorf("%d. xml decoded %+v, json decoded %+v", i, x, j)
+		}
+	}
+}
+
+func TestXMLNoFormDrift(t *testing.T) {
+	d := NewDriftRecorder()
+	s := `<tracks><track><trackId>1</trackId><trackNumber>3</trackNumber><streamable>true</streamable><colour>blue</colour></track></tracks>`
+
+	var ts []Track
+	if err := decodeXML("tracksInfo", strings.NewReader(s), &ts, d); err != nil {
+		t.Fatal(err)
+	}
+	r := d.Report()["tracksInfo"]
+	if len(r.TypeMismatches) != 0 {
+		t.Errorf("text values recorded as drift: %v", r.TypeMismatches)
+	}
+	if r.UnknownFields["[].colour"] != 1 {
+		t.Errorf("unknown field not recorded: %v", r.UnknownFields)
+	}
+}
+
+func TestResponseFormat(t *testing.T) {
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"tracksInfo": func(w http.ResponseWriter, r *http.Request) {
+			if f := r.URL.Query().Get("format"); f != "xml" {
+				t.Errorf("requested format %q", f)
+			}
+			http.ServeFile(w, r, "testdata/tracksInfo.xml")
+		},
+	})
+	if c.ResponseFormat() != JSON {
+		t.Errorf("default format %q", c.ResponseFormat())
+	}
+	c.Option(ResponseFormat(XML))
+
+	ts, err := c.TracksInfo(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 || ts[0].Title != "Purple Haze" || !ts[0].Streamable {
+		t.Errorf("unexpected tracks %+v", ts)
+	}
+}